@@ -2,6 +2,11 @@ module github.com/sunls24/gox
 
 go 1.25.5
 
-require github.com/labstack/echo/v5 v5.3.1
-
-require golang.org/x/time v0.15.0 // indirect
+require (
+	github.com/andybalholm/brotli v1.2.0
+	github.com/klauspost/compress v1.18.0
+	github.com/labstack/echo/v5 v5.3.1
+	golang.org/x/net v0.57.0
+	golang.org/x/text v0.40.0
+	golang.org/x/time v0.15.0
+)
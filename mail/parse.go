@@ -0,0 +1,271 @@
+package mail
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	stdmail "net/mail"
+	"net/textproto"
+	"strings"
+	"time"
+
+	"golang.org/x/text/encoding/htmlindex"
+)
+
+type Message struct {
+	Header      stdmail.Header
+	From        []*stdmail.Address
+	To          []*stdmail.Address
+	Cc          []*stdmail.Address
+	Subject     string
+	MessageID   string
+	InReplyTo   string
+	Date        time.Time
+	Text        string
+	HTML        string
+	Attachments []Attachment
+	Bounce      *Bounce
+}
+
+type Attachment struct {
+	Filename    string
+	ContentType string
+	ContentID   string
+	Inline      bool
+	Data        []byte
+}
+
+// Bounce is the per-recipient part of an RFC 3464 delivery status notification.
+type Bounce struct {
+	ReportingMTA      string
+	OriginalRecipient string
+	FinalRecipient    string
+	Action            string
+	Status            string
+	DiagnosticCode    string
+}
+
+// Failed reports whether the notification is a permanent or transient failure
+// rather than a delay or success report.
+func (b *Bounce) Failed() bool {
+	return b.Action == "failed" || strings.HasPrefix(b.Status, "5.")
+}
+
+const maxPartDepth = 16
+
+var wordDecoder = &mime.WordDecoder{CharsetReader: charsetReader}
+
+func Parse(r io.Reader) (*Message, error) {
+	raw, err := stdmail.ReadMessage(r)
+	if err != nil {
+		return nil, fmt.Errorf("read mail message: %w", err)
+	}
+	m := &Message{
+		Header:    raw.Header,
+		Subject:   decodeHeader(raw.Header.Get("Subject")),
+		MessageID: strings.TrimSpace(raw.Header.Get("Message-ID")),
+		InReplyTo: strings.TrimSpace(raw.Header.Get("In-Reply-To")),
+	}
+	m.From = parseAddressList(raw.Header, "From")
+	m.To = parseAddressList(raw.Header, "To")
+	m.Cc = parseAddressList(raw.Header, "Cc")
+	if date, err := raw.Header.Date(); err == nil {
+		m.Date = date
+	}
+	header := textproto.MIMEHeader(raw.Header)
+	if err := m.parsePart(header, raw.Body, 0); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func ParseBytes(data []byte) (*Message, error) {
+	return Parse(bytes.NewReader(data))
+}
+
+func (m *Message) parsePart(header textproto.MIMEHeader, body io.Reader, depth int) error {
+	if depth > maxPartDepth {
+		return errors.New("mail message nested too deeply")
+	}
+	mediaType, params := parseContentType(header.Get("Content-Type"))
+	if strings.HasPrefix(mediaType, "multipart/") {
+		boundary := params["boundary"]
+		if boundary == "" {
+			return fmt.Errorf("mail %s part has no boundary", mediaType)
+		}
+		reader := multipart.NewReader(body, boundary)
+		for {
+			part, err := reader.NextRawPart()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return fmt.Errorf("read mail part: %w", err)
+			}
+			if err := m.parsePart(part.Header, part, depth+1); err != nil {
+				return err
+			}
+		}
+	}
+
+	data, err := io.ReadAll(decodeTransfer(header.Get("Content-Transfer-Encoding"), body))
+	if err != nil {
+		return fmt.Errorf("decode mail part: %w", err)
+	}
+	disposition, dispositionParams, _ := mime.ParseMediaType(header.Get("Content-Disposition"))
+	filename := decodeHeader(dispositionParams["filename"])
+	if filename == "" {
+		filename = decodeHeader(params["name"])
+	}
+
+	switch {
+	case mediaType == "message/delivery-status":
+		if bounce := parseDeliveryStatus(data); bounce != nil && m.Bounce == nil {
+			m.Bounce = bounce
+		}
+	case disposition != "attachment" && filename == "" && mediaType == "text/plain" && m.Text == "":
+		m.Text = decodeCharset(params["charset"], data)
+	case disposition != "attachment" && filename == "" && mediaType == "text/html" && m.HTML == "":
+		m.HTML = decodeCharset(params["charset"], data)
+	default:
+		m.Attachments = append(m.Attachments, Attachment{
+			Filename:    filename,
+			ContentType: mediaType,
+			ContentID:   strings.Trim(header.Get("Content-ID"), "<>"),
+			Inline:      disposition == "inline",
+			Data:        data,
+		})
+	}
+	return nil
+}
+
+func parseContentType(value string) (string, map[string]string) {
+	if value == "" {
+		return "text/plain", map[string]string{}
+	}
+	mediaType, params, err := mime.ParseMediaType(value)
+	if err != nil {
+		return "application/octet-stream", map[string]string{}
+	}
+	return mediaType, params
+}
+
+func parseAddressList(header stdmail.Header, key string) []*stdmail.Address {
+	if header.Get(key) == "" {
+		return nil
+	}
+	list, err := header.AddressList(key)
+	if err != nil {
+		return nil
+	}
+	return list
+}
+
+func decodeHeader(value string) string {
+	decoded, err := wordDecoder.DecodeHeader(value)
+	if err != nil {
+		return value
+	}
+	return decoded
+}
+
+func decodeTransfer(encoding string, body io.Reader) io.Reader {
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "base64":
+		return base64.NewDecoder(base64.StdEncoding, &base64Cleaner{r: body})
+	case "quoted-printable":
+		return quotedprintable.NewReader(body)
+	default:
+		return body
+	}
+}
+
+// base64Cleaner drops line breaks and other whitespace that mail encoders
+// insert between base64 lines.
+type base64Cleaner struct {
+	r io.Reader
+}
+
+func (c *base64Cleaner) Read(p []byte) (int, error) {
+	for {
+		n, err := c.r.Read(p)
+		j := 0
+		for _, b := range p[:n] {
+			if b == '\r' || b == '\n' || b == ' ' || b == '\t' {
+				continue
+			}
+			p[j] = b
+			j++
+		}
+		if j > 0 || err != nil {
+			return j, err
+		}
+	}
+}
+
+func decodeCharset(charset string, data []byte) string {
+	reader, err := charsetReader(charset, bytes.NewReader(data))
+	if err != nil {
+		return string(data)
+	}
+	decoded, err := io.ReadAll(reader)
+	if err != nil {
+		return string(data)
+	}
+	return string(decoded)
+}
+
+// charsetReader decodes the charsets known to the WHATWG encoding standard,
+// which covers GBK, GB18030, Big5, Shift_JIS and the Windows code pages
+func charsetReader(charset string, input io.Reader) (io.Reader, error) {
+	charset = strings.ToLower(strings.TrimSpace(charset))
+	switch charset {
+	case "", "utf-8", "utf8", "us-ascii", "ascii":
+		return input, nil
+	}
+	encoding, err := htmlindex.Get(charset)
+	if err != nil {
+		return nil, fmt.Errorf("unsupported charset %q", charset)
+	}
+	return encoding.NewDecoder().Reader(input), nil
+}
+
+// parseDeliveryStatus reads the per-message and first per-recipient field
+// groups of a message/delivery-status body.
+func parseDeliveryStatus(data []byte) *Bounce {
+	reader := textproto.NewReader(bufio.NewReader(bytes.NewReader(data)))
+	perMessage, err := reader.ReadMIMEHeader()
+	if err != nil && len(perMessage) == 0 {
+		return nil
+	}
+	perRecipient, err := reader.ReadMIMEHeader()
+	if err != nil && len(perRecipient) == 0 {
+		return nil
+	}
+	bounce := &Bounce{
+		ReportingMTA:      dsnValue(perMessage.Get("Reporting-MTA")),
+		OriginalRecipient: dsnValue(perRecipient.Get("Original-Recipient")),
+		FinalRecipient:    dsnValue(perRecipient.Get("Final-Recipient")),
+		Action:            strings.ToLower(strings.TrimSpace(perRecipient.Get("Action"))),
+		Status:            strings.TrimSpace(perRecipient.Get("Status")),
+		DiagnosticCode:    dsnValue(perRecipient.Get("Diagnostic-Code")),
+	}
+	if bounce.FinalRecipient == "" && bounce.Status == "" {
+		return nil
+	}
+	return bounce
+}
+
+// dsnValue strips the type prefix from fields such as "rfc822; user@example.com".
+func dsnValue(value string) string {
+	if _, after, ok := strings.Cut(value, ";"); ok {
+		value = after
+	}
+	return strings.TrimSpace(value)
+}
@@ -0,0 +1,124 @@
+package mail
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseMultipart(t *testing.T) {
+	raw := strings.Join([]string{
+		"From: =?UTF-8?B?5byg5LiJ?= <sender@example.com>",
+		"To: user@example.com",
+		"Subject: =?UTF-8?q?Re:_=E9=AA=8C=E8=AF=81=E7=A0=81?=",
+		"Date: Mon, 19 Oct 2026 10:00:00 +0800",
+		"MIME-Version: 1.0",
+		`Content-Type: multipart/mixed; boundary="outer"`,
+		"",
+		"--outer",
+		`Content-Type: multipart/alternative; boundary="inner"`,
+		"",
+		"--inner",
+		"Content-Type: text/plain; charset=UTF-8",
+		"Content-Transfer-Encoding: quoted-printable",
+		"",
+		"=E4=BD=A0=E5=A5=BD",
+		"--inner",
+		"Content-Type: text/html; charset=UTF-8",
+		"",
+		"<p>hello</p>",
+		"--inner--",
+		"--outer",
+		`Content-Type: application/octet-stream; name="a.txt"`,
+		"Content-Disposition: attachment; filename=\"a.txt\"",
+		"Content-Transfer-Encoding: base64",
+		"",
+		"aGVs",
+		"bG8=",
+		"--outer--",
+		"",
+	}, "\r\n")
+	message, err := Parse(strings.NewReader(raw))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if message.Subject != "Re: 验证码" {
+		t.Errorf("subject = %q, want %q", message.Subject, "Re: 验证码")
+	}
+	if len(message.From) != 1 || message.From[0].Name != "张三" {
+		t.Errorf("from = %v, want 张三", message.From)
+	}
+	if message.Text != "你好" {
+		t.Errorf("text = %q, want %q", message.Text, "你好")
+	}
+	if message.HTML != "<p>hello</p>" {
+		t.Errorf("html = %q, want %q", message.HTML, "<p>hello</p>")
+	}
+	if len(message.Attachments) != 1 || string(message.Attachments[0].Data) != "hello" {
+		t.Fatalf("attachments = %+v, want a.txt with hello", message.Attachments)
+	}
+	if message.Attachments[0].Filename != "a.txt" {
+		t.Errorf("filename = %q, want a.txt", message.Attachments[0].Filename)
+	}
+}
+
+func TestParseBounce(t *testing.T) {
+	raw := strings.Join([]string{
+		"From: MAILER-DAEMON@example.com",
+		"To: sender@example.com",
+		"Subject: Undelivered Mail Returned to Sender",
+		`Content-Type: multipart/report; report-type=delivery-status; boundary="b"`,
+		"",
+		"--b",
+		"Content-Type: text/plain",
+		"",
+		"delivery failed",
+		"--b",
+		"Content-Type: message/delivery-status",
+		"",
+		"Reporting-MTA: dns; mx.example.com",
+		"",
+		"Final-Recipient: rfc822; nobody@example.org",
+		"Action: failed",
+		"Status: 5.1.1",
+		"Diagnostic-Code: smtp; 550 5.1.1 user unknown",
+		"--b--",
+		"",
+	}, "\r\n")
+	message, err := Parse(strings.NewReader(raw))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if message.Bounce == nil {
+		t.Fatal("bounce = nil, want delivery status")
+	}
+	if message.Bounce.FinalRecipient != "nobody@example.org" {
+		t.Errorf("recipient = %q, want nobody@example.org", message.Bounce.FinalRecipient)
+	}
+	if message.Bounce.Status != "5.1.1" || !message.Bounce.Failed() {
+		t.Errorf("status = %q, want failed 5.1.1", message.Bounce.Status)
+	}
+	if message.Bounce.ReportingMTA != "mx.example.com" {
+		t.Errorf("reporting MTA = %q, want mx.example.com", message.Bounce.ReportingMTA)
+	}
+}
+
+func TestParseCharsets(t *testing.T) {
+	raw := strings.Join([]string{
+		"From: sender@example.com",
+		"Subject: =?GBK?B?1tDOxA==?=",
+		"Content-Type: text/plain; charset=windows-1252",
+		"Content-Transfer-Encoding: quoted-printable",
+		"",
+		"=80 =93ok=94",
+	}, "\r\n")
+	message, err := Parse(strings.NewReader(raw))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if message.Subject != "中文" {
+		t.Errorf("subject = %q, want %q", message.Subject, "中文")
+	}
+	if message.Text != "€ “ok”" {
+		t.Errorf("text = %q, want %q", message.Text, "€ “ok”")
+	}
+}
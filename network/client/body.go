@@ -0,0 +1,118 @@
+package client
+
+import (
+	"io"
+	"mime/multipart"
+	"net/textproto"
+	"strings"
+	"sync"
+)
+
+// Body a request body that knows its Content-Type
+type Body interface {
+	io.Reader
+	ContentType() string
+}
+
+// Multipart streams a multipart/form-data body, parts are written while the
+// request is sent instead of being buffered in memory
+type Multipart struct {
+	reader *io.PipeReader
+	writer *io.PipeWriter
+	form   *multipart.Writer
+	parts  []formPart
+	once   sync.Once
+}
+
+type formPart struct {
+	header textproto.MIMEHeader
+	value  string
+	reader io.Reader
+}
+
+func NewMultipart() *Multipart {
+	reader, writer := io.Pipe()
+	return &Multipart{
+		reader: reader,
+		writer: writer,
+		form:   multipart.NewWriter(writer),
+	}
+}
+
+func (m *Multipart) Field(name, value string) *Multipart {
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Disposition", "form-data; name="+quote(name))
+	m.parts = append(m.parts, formPart{header: header, value: value})
+	return m
+}
+
+// File reader implementing io.Closer is closed once written
+func (m *Multipart) File(name, filename string, reader io.Reader) *Multipart {
+	return m.FileWithType(name, filename, "application/octet-stream", reader)
+}
+
+func (m *Multipart) FileWithType(name, filename, contentType string, reader io.Reader) *Multipart {
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Disposition", "form-data; name="+quote(name)+"; filename="+quote(filename))
+	header.Set("Content-Type", contentType)
+	m.parts = append(m.parts, formPart{header: header, reader: reader})
+	return m
+}
+
+func (m *Multipart) ContentType() string {
+	return m.form.FormDataContentType()
+}
+
+func (m *Multipart) Read(p []byte) (int, error) {
+	m.once.Do(func() {
+		go m.write()
+	})
+	return m.reader.Read(p)
+}
+
+// Close stops writing parts, the transport calls it when the request ends
+func (m *Multipart) Close() error {
+	started := true
+	m.once.Do(func() {
+		started = false
+	})
+	if !started {
+		m.closeParts()
+	}
+	return m.reader.Close()
+}
+
+func (m *Multipart) write() {
+	defer m.closeParts()
+	for _, p := range m.parts {
+		w, err := m.form.CreatePart(p.header)
+		if err != nil {
+			m.writer.CloseWithError(err)
+			return
+		}
+		if p.reader == nil {
+			_, err = io.WriteString(w, p.value)
+		} else {
+			_, err = io.Copy(w, p.reader)
+		}
+		if err != nil {
+			m.writer.CloseWithError(err)
+			return
+		}
+	}
+	m.writer.CloseWithError(m.form.Close())
+}
+
+func (m *Multipart) closeParts() {
+	for _, p := range m.parts {
+		if closer, ok := p.reader.(io.Closer); ok {
+			_ = closer.Close()
+		}
+	}
+}
+
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"", "\r", "%0D", "\n", "%0A")
+
+func quote(s string) string {
+	return `"` + quoteEscaper.Replace(s) + `"`
+}
@@ -0,0 +1,54 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestPostForm(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		_, _ = w.Write([]byte(r.Header.Get("Content-Type") + "|" + r.PostForm.Get("q")))
+	}))
+	defer server.Close()
+
+	body, err := New().Post(context.Background(), server.URL, url.Values{"q": {"a b"}})
+	if err != nil {
+		t.Fatalf("Post() error = %v", err)
+	}
+	if want := "application/x-www-form-urlencoded|a b"; string(body) != want {
+		t.Fatalf("body = %q, want %q", body, want)
+	}
+}
+
+func TestPostMultipart(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		defer file.Close()
+		data, _ := io.ReadAll(file)
+		_, _ = w.Write([]byte(r.FormValue("name") + "|" + header.Filename + "|" + string(data)))
+	}))
+	defer server.Close()
+
+	form := NewMultipart().Field("name", "gox").File("file", "a.txt", strings.NewReader("hello"))
+	body, err := New().Post(context.Background(), server.URL, form)
+	if err != nil {
+		t.Fatalf("Post() error = %v", err)
+	}
+	if want := "gox|a.txt|hello"; string(body) != want {
+		t.Fatalf("body = %q, want %q", body, want)
+	}
+}
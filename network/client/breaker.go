@@ -0,0 +1,215 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+type BreakerState int
+
+const (
+	StateClosed BreakerState = iota
+	StateOpen
+	StateHalfOpen
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half-open"
+	}
+	return fmt.Sprintf("BreakerState(%d)", int(s))
+}
+
+type Breaker struct {
+	// FailureRatio opens the circuit once failures/requests within Window reaches it
+	FailureRatio float64
+	// MinRequests within Window before FailureRatio is evaluated
+	MinRequests int
+	Window      time.Duration
+	// CoolDown how long the circuit stays open before letting probes through
+	CoolDown time.Duration
+	// HalfOpenRequests probes allowed in half-open state, all must succeed to close
+	HalfOpenRequests int
+	// IsFailure defaults to network errors and 5xx responses
+	IsFailure     func(resp *http.Response, err error) bool
+	OnStateChange func(host string, from, to BreakerState)
+}
+
+// WithBreaker keeps a separate circuit for each host
+func WithBreaker(breaker Breaker) Option {
+	return func(c *Client) {
+		if breaker.FailureRatio <= 0 || breaker.FailureRatio > 1 {
+			breaker.FailureRatio = 0.5
+		}
+		if breaker.MinRequests <= 0 {
+			breaker.MinRequests = 10
+		}
+		if breaker.Window <= 0 {
+			breaker.Window = time.Minute
+		}
+		if breaker.CoolDown <= 0 {
+			breaker.CoolDown = time.Second * 30
+		}
+		if breaker.HalfOpenRequests <= 0 {
+			breaker.HalfOpenRequests = 1
+		}
+		if breaker.IsFailure == nil {
+			breaker.IsFailure = isFailure
+		}
+		c.breakers = &breakers{config: breaker, hosts: make(map[string]*circuit)}
+	}
+}
+
+// BreakerState returns StateClosed for hosts without a circuit
+func (c *Client) BreakerState(host string) BreakerState {
+	if c.breakers == nil {
+		return StateClosed
+	}
+	c.breakers.m.Lock()
+	defer c.breakers.m.Unlock()
+	if cb, ok := c.breakers.hosts[strings.ToLower(host)]; ok {
+		return cb.state
+	}
+	return StateClosed
+}
+
+func isFailure(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	return resp.StatusCode >= http.StatusInternalServerError
+}
+
+type breakers struct {
+	config Breaker
+	m      sync.Mutex
+	hosts  map[string]*circuit
+}
+
+type circuit struct {
+	// generation changes with every state change, outcomes of requests
+	// admitted in an earlier generation are ignored
+	generation uint64
+	state      BreakerState
+	since      time.Time
+	requests   int
+	failures   int
+	inFlight   int
+	successes  int
+}
+
+func (b *breakers) allow(host string) (uint64, error) {
+	b.m.Lock()
+	cb, ok := b.hosts[host]
+	if !ok {
+		cb = &circuit{since: time.Now()}
+		b.hosts[host] = cb
+	}
+	var from BreakerState
+	changed := false
+	now := time.Now()
+	switch cb.state {
+	case StateClosed:
+		if now.Sub(cb.since) > b.config.Window {
+			cb.since, cb.requests, cb.failures = now, 0, 0
+		}
+	case StateOpen:
+		if now.Sub(cb.since) < b.config.CoolDown {
+			b.m.Unlock()
+			return 0, fmt.Errorf("%w: %s", ErrCircuitOpen, host)
+		}
+		from, changed = cb.state, true
+		cb.set(StateHalfOpen, now)
+	}
+	if cb.state == StateHalfOpen {
+		if cb.inFlight >= b.config.HalfOpenRequests-cb.successes {
+			b.m.Unlock()
+			b.notify(host, from, StateHalfOpen, changed)
+			return 0, fmt.Errorf("%w: %s", ErrCircuitOpen, host)
+		}
+		cb.inFlight++
+	}
+	generation := cb.generation
+	b.m.Unlock()
+	b.notify(host, from, StateHalfOpen, changed)
+	return generation, nil
+}
+
+func (b *breakers) record(host string, generation uint64, failed bool) {
+	b.m.Lock()
+	cb := b.hosts[host]
+	if cb.generation != generation {
+		b.m.Unlock()
+		return
+	}
+	from := cb.state
+	now := time.Now()
+	switch cb.state {
+	case StateClosed:
+		cb.requests++
+		if failed {
+			cb.failures++
+		}
+		if cb.requests >= b.config.MinRequests &&
+			float64(cb.failures)/float64(cb.requests) >= b.config.FailureRatio {
+			cb.set(StateOpen, now)
+		}
+	case StateHalfOpen:
+		cb.inFlight--
+		if failed {
+			cb.set(StateOpen, now)
+		} else if cb.successes++; cb.successes >= b.config.HalfOpenRequests {
+			cb.set(StateClosed, now)
+		}
+	}
+	to := cb.state
+	b.m.Unlock()
+	b.notify(host, from, to, from != to)
+}
+
+// release frees a half-open probe without counting the outcome
+func (b *breakers) release(host string, generation uint64) {
+	b.m.Lock()
+	defer b.m.Unlock()
+	if cb := b.hosts[host]; cb.generation == generation && cb.state == StateHalfOpen {
+		cb.inFlight--
+	}
+}
+
+func (b *breakers) notify(host string, from, to BreakerState, changed bool) {
+	if changed && b.config.OnStateChange != nil {
+		b.config.OnStateChange(host, from, to)
+	}
+}
+
+func (cb *circuit) set(state BreakerState, now time.Time) {
+	*cb = circuit{generation: cb.generation + 1, state: state, since: now}
+}
+
+// roundTrip failures of the proxy in front of the host are not held against it
+func (b *breakers) roundTrip(req *http.Request, next RoundTrip) (*http.Response, error) {
+	host := strings.ToLower(req.URL.Hostname())
+	generation, err := b.allow(host)
+	if err != nil {
+		closeBody(req)
+		return nil, err
+	}
+	resp, err := next(req)
+	if err != nil && req.Context().Err() != nil || proxyFailure(resp, err) {
+		b.release(host, generation)
+		return resp, err
+	}
+	b.record(host, generation, b.config.IsFailure(resp, err))
+	return resp, err
+}
@@ -0,0 +1,128 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+func TestBreaker(t *testing.T) {
+	var healthy atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !healthy.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	var changes []string
+	c := New(WithBreaker(Breaker{
+		MinRequests: 2,
+		CoolDown:    time.Millisecond * 20,
+		OnStateChange: func(host string, from, to BreakerState) {
+			changes = append(changes, from.String()+">"+to.String())
+		},
+	}))
+	ctx := context.Background()
+	for range 2 {
+		if _, err := c.Get(ctx, server.URL); errors.Is(err, ErrCircuitOpen) {
+			t.Fatalf("Get() error = %v, want upstream error", err)
+		}
+	}
+	if _, err := c.Get(ctx, server.URL); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("Get() error = %v, want ErrCircuitOpen", err)
+	}
+	if state := c.BreakerState("127.0.0.1"); state != StateOpen {
+		t.Fatalf("state = %s, want open", state)
+	}
+
+	healthy.Store(true)
+	time.Sleep(time.Millisecond * 30)
+	if _, err := c.Get(ctx, server.URL); err != nil {
+		t.Fatalf("Get() error = %v, want nil after cool-down", err)
+	}
+	want := []string{"closed>open", "open>half-open", "half-open>closed"}
+	if len(changes) != len(want) {
+		t.Fatalf("changes = %v, want %v", changes, want)
+	}
+	for i := range want {
+		if changes[i] != want[i] {
+			t.Fatalf("changes = %v, want %v", changes, want)
+		}
+	}
+}
+
+func TestBreakerIgnoresStaleOutcome(t *testing.T) {
+	b := &breakers{
+		config: Breaker{FailureRatio: 0.5, MinRequests: 1, Window: time.Minute, CoolDown: time.Millisecond, HalfOpenRequests: 1},
+		hosts:  make(map[string]*circuit),
+	}
+	slow, _ := b.allow("h")
+	failed, _ := b.allow("h")
+	b.record("h", failed, true)
+	time.Sleep(time.Millisecond * 2)
+	probe, err := b.allow("h")
+	if err != nil {
+		t.Fatalf("allow() error = %v, want probe admitted", err)
+	}
+	// the request sent before the circuit opened must not close it
+	b.record("h", slow, false)
+	if state := b.hosts["h"].state; state != StateHalfOpen {
+		t.Fatalf("state = %s, want half-open", state)
+	}
+	b.record("h", probe, true)
+	if state := b.hosts["h"].state; state != StateOpen {
+		t.Fatalf("state = %s, want open", state)
+	}
+}
+
+func TestBreakerIgnoresRateLimitErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	c := New(WithRateLimit(rate.Every(time.Hour), 1), WithBreaker(Breaker{MinRequests: 1}))
+	if _, err := c.Get(context.Background(), server.URL); err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	for range 3 {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		if _, err := c.Get(ctx, server.URL); err == nil {
+			t.Fatal("Get() error = nil, want rate limit error")
+		}
+		cancel()
+	}
+	if state := c.BreakerState("127.0.0.1"); state != StateClosed {
+		t.Fatalf("state = %s, want closed", state)
+	}
+}
+
+type closeRecorder struct {
+	io.Reader
+	closed atomic.Bool
+}
+
+func (r *closeRecorder) Close() error {
+	r.closed.Store(true)
+	return nil
+}
+
+func TestBreakerClosesBody(t *testing.T) {
+	c := New(WithBreaker(Breaker{MinRequests: 1}))
+	c.breakers.hosts["example.com"] = &circuit{state: StateOpen, since: time.Now()}
+	body := &closeRecorder{Reader: strings.NewReader("data")}
+	req, _ := http.NewRequest(http.MethodPost, "http://example.com", body)
+	if _, err := c.Do(req); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("Do() error = %v, want ErrCircuitOpen", err)
+	}
+	if !body.closed.Load() {
+		t.Fatal("request body was not closed")
+	}
+}
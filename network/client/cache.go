@@ -0,0 +1,445 @@
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httputil"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const maxCacheBody = 10 << 20
+
+// CacheStore values are opaque serialized responses
+type CacheStore interface {
+	Get(key string) ([]byte, bool)
+	Set(key string, value []byte)
+	Delete(key string)
+}
+
+// WithCache caches GET responses as a private cache following RFC 9111. Entries
+// are keyed by URL, so responses to requests with an Authorization header are
+// only stored and served when marked public. Cookies are added by the cookie
+// jar after the cache runs, do not combine WithCache with WithJar or
+// WithCookieJar when responses depend on the account.
+func WithCache(store CacheStore) Option {
+	return WithMiddleware(CacheMiddleware(store))
+}
+
+func CacheMiddleware(store CacheStore) Middleware {
+	return func(next RoundTrip) RoundTrip {
+		return func(req *http.Request) (*http.Response, error) {
+			return cacheRoundTrip(store, req, next)
+		}
+	}
+}
+
+type cacheEntry struct {
+	RequestTime  time.Time           `json:"request_time"`
+	ResponseTime time.Time           `json:"response_time"`
+	Vary         map[string][]string `json:"vary,omitempty"`
+	Response     []byte              `json:"response"`
+}
+
+func cacheRoundTrip(store CacheStore, req *http.Request, next RoundTrip) (*http.Response, error) {
+	key := req.URL.String()
+	if req.Method != http.MethodGet {
+		resp, err := next(req)
+		if err == nil && req.Method != http.MethodHead && resp.StatusCode < http.StatusBadRequest {
+			store.Delete(key)
+		}
+		return resp, err
+	}
+	reqControl := parseCacheControl(req.Header)
+	if _, ok := reqControl["no-store"]; ok {
+		return next(req)
+	}
+
+	authorized := req.Header.Get("Authorization") != ""
+	entry, cached := loadCacheEntry(store, key, req)
+	if cached {
+		resp, err := entry.response(req)
+		if err != nil || authorized && !public(resp.Header) {
+			cached = false
+		} else if entry.fresh(resp.Header, reqControl) {
+			resp.Header.Set("Age", strconv.FormatInt(int64(entry.age(resp.Header)/time.Second), 10))
+			return resp, nil
+		} else if conditional := revalidation(req, resp.Header); conditional != nil {
+			req = conditional
+		}
+	}
+
+	requestTime := time.Now()
+	resp, err := next(req)
+	if err != nil {
+		return nil, err
+	}
+	responseTime := time.Now()
+	if cached && resp.StatusCode == http.StatusNotModified {
+		Close(resp.Body)
+		return refreshResponse(store, key, req, entry, resp.Header, requestTime, responseTime)
+	}
+	if !cacheable(resp) || authorized && !public(resp.Header) {
+		return resp, nil
+	}
+	return storeResponse(store, key, req, resp, requestTime, responseTime)
+}
+
+func storeResponse(store CacheStore, key string, req *http.Request, resp *http.Response, requestTime, responseTime time.Time) (*http.Response, error) {
+	if resp.ContentLength > maxCacheBody {
+		return resp, nil
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxCacheBody+1))
+	if err != nil {
+		Close(resp.Body)
+		return nil, err
+	}
+	if len(body) > maxCacheBody {
+		resp.Body = readCloser{io.MultiReader(bytes.NewReader(body), resp.Body), resp.Body}
+		return resp, nil
+	}
+	Close(resp.Body)
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	data, err := dumpResponse(resp.Status, resp.StatusCode, resp.Header, body)
+	if err != nil {
+		return resp, nil
+	}
+	entry := &cacheEntry{
+		RequestTime:  requestTime,
+		ResponseTime: responseTime,
+		Response:     data,
+	}
+	for _, name := range varyHeaders(resp.Header) {
+		if entry.Vary == nil {
+			entry.Vary = make(map[string][]string)
+		}
+		entry.Vary[name] = req.Header.Values(name)
+	}
+	saveCacheEntry(store, key, entry)
+	return resp, nil
+}
+
+// refreshResponse updates the stored response with the headers of a 304
+func refreshResponse(store CacheStore, key string, req *http.Request, entry *cacheEntry, header http.Header, requestTime, responseTime time.Time) (*http.Response, error) {
+	stored, err := entry.response(req)
+	if err != nil {
+		return nil, err
+	}
+	body, err := io.ReadAll(stored.Body)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range header {
+		stored.Header[k] = v
+	}
+	stored.Header.Del("Age")
+	data, err := dumpResponse(stored.Status, stored.StatusCode, stored.Header, body)
+	if err != nil {
+		return nil, err
+	}
+	entry.RequestTime, entry.ResponseTime, entry.Response = requestTime, responseTime, data
+	saveCacheEntry(store, key, entry)
+	return entry.response(req)
+}
+
+func dumpResponse(status string, statusCode int, header http.Header, body []byte) ([]byte, error) {
+	header = header.Clone()
+	header.Del("Transfer-Encoding")
+	header.Del("Content-Length")
+	return httputil.DumpResponse(&http.Response{
+		Status:        status,
+		StatusCode:    statusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+	}, true)
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+func loadCacheEntry(store CacheStore, key string, req *http.Request) (*cacheEntry, bool) {
+	data, ok := store.Get(key)
+	if !ok {
+		return nil, false
+	}
+	var entry cacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		store.Delete(key)
+		return nil, false
+	}
+	for name, values := range entry.Vary {
+		if !slices.Equal(req.Header.Values(name), values) {
+			return nil, false
+		}
+	}
+	return &entry, true
+}
+
+func saveCacheEntry(store CacheStore, key string, entry *cacheEntry) {
+	if data, err := json.Marshal(entry); err == nil {
+		store.Set(key, data)
+	}
+}
+
+func (e *cacheEntry) response(req *http.Request) (*http.Response, error) {
+	return http.ReadResponse(bufio.NewReader(bytes.NewReader(e.Response)), req)
+}
+
+// age is current_age of RFC 9111 section 4.2.3
+func (e *cacheEntry) age(header http.Header) time.Duration {
+	apparent := time.Duration(0)
+	if date, err := http.ParseTime(header.Get("Date")); err == nil {
+		apparent = max(e.ResponseTime.Sub(date), 0)
+	}
+	ageValue, _ := strconv.ParseInt(header.Get("Age"), 10, 64)
+	corrected := time.Duration(ageValue)*time.Second + e.ResponseTime.Sub(e.RequestTime)
+	return max(apparent, corrected) + time.Since(e.ResponseTime)
+}
+
+// lifetime is the freshness lifetime of RFC 9111 section 4.2.1, falling back
+// to 10% of the time since Last-Modified
+func (e *cacheEntry) lifetime(header http.Header, control map[string]string) time.Duration {
+	if seconds, ok := control["max-age"]; ok {
+		if n, err := strconv.ParseInt(seconds, 10, 64); err == nil {
+			return time.Duration(n) * time.Second
+		}
+		return 0
+	}
+	date, err := http.ParseTime(header.Get("Date"))
+	if err != nil {
+		date = e.ResponseTime
+	}
+	if expires := header.Get("Expires"); expires != "" {
+		t, err := http.ParseTime(expires)
+		if err != nil {
+			return 0
+		}
+		return t.Sub(date)
+	}
+	if lastModified, err := http.ParseTime(header.Get("Last-Modified")); err == nil && lastModified.Before(date) {
+		return date.Sub(lastModified) / 10
+	}
+	return 0
+}
+
+func (e *cacheEntry) fresh(header http.Header, reqControl map[string]string) bool {
+	control := parseCacheControl(header)
+	if _, ok := control["no-cache"]; ok {
+		return false
+	}
+	if _, ok := reqControl["no-cache"]; ok {
+		return false
+	}
+	lifetime := e.lifetime(header, control)
+	if seconds, ok := reqControl["max-age"]; ok {
+		if n, err := strconv.ParseInt(seconds, 10, 64); err == nil {
+			lifetime = min(lifetime, time.Duration(n)*time.Second)
+		}
+	}
+	age := e.age(header)
+	if seconds, ok := reqControl["min-fresh"]; ok {
+		if n, err := strconv.ParseInt(seconds, 10, 64); err == nil {
+			age += time.Duration(n) * time.Second
+		}
+	}
+	return age < lifetime
+}
+
+func revalidation(req *http.Request, header http.Header) *http.Request {
+	etag, lastModified := header.Get("ETag"), header.Get("Last-Modified")
+	if etag == "" && lastModified == "" {
+		return nil
+	}
+	conditional := req.Clone(req.Context())
+	if etag != "" {
+		conditional.Header.Set("If-None-Match", etag)
+	}
+	if lastModified != "" {
+		conditional.Header.Set("If-Modified-Since", lastModified)
+	}
+	return conditional
+}
+
+var cacheableStatus = []int{
+	http.StatusOK,
+	http.StatusNonAuthoritativeInfo,
+	http.StatusNoContent,
+	http.StatusMultipleChoices,
+	http.StatusMovedPermanently,
+	http.StatusPermanentRedirect,
+	http.StatusNotFound,
+	http.StatusMethodNotAllowed,
+	http.StatusGone,
+	http.StatusRequestURITooLong,
+	http.StatusNotImplemented,
+}
+
+func cacheable(resp *http.Response) bool {
+	if !slices.Contains(cacheableStatus, resp.StatusCode) {
+		return false
+	}
+	control := parseCacheControl(resp.Header)
+	if _, ok := control["no-store"]; ok {
+		return false
+	}
+	if slices.Contains(varyHeaders(resp.Header), "*") {
+		return false
+	}
+	_, maxAge := control["max-age"]
+	return maxAge || resp.Header.Get("Expires") != "" ||
+		resp.Header.Get("ETag") != "" || resp.Header.Get("Last-Modified") != ""
+}
+
+// public responses may be shared between credentials, RFC 9111 section 3.5
+func public(header http.Header) bool {
+	_, ok := parseCacheControl(header)["public"]
+	return ok
+}
+
+func parseCacheControl(header http.Header) map[string]string {
+	control := make(map[string]string)
+	for _, value := range header.Values("Cache-Control") {
+		for _, directive := range strings.Split(value, ",") {
+			name, arg, _ := strings.Cut(strings.TrimSpace(directive), "=")
+			if name != "" {
+				control[strings.ToLower(name)] = strings.Trim(arg, `"`)
+			}
+		}
+	}
+	return control
+}
+
+func varyHeaders(header http.Header) []string {
+	var names []string
+	for _, value := range header.Values("Vary") {
+		for _, name := range strings.Split(value, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				names = append(names, http.CanonicalHeaderKey(name))
+			}
+		}
+	}
+	return names
+}
+
+type memoryCache struct {
+	m          sync.Mutex
+	maxEntries int
+	order      *list.List
+	items      map[string]*list.Element
+}
+
+type memoryItem struct {
+	key   string
+	value []byte
+}
+
+// NewMemoryCache evicts the least recently used entry beyond maxEntries, 0 means unlimited
+func NewMemoryCache(maxEntries int) CacheStore {
+	return &memoryCache{
+		maxEntries: maxEntries,
+		order:      list.New(),
+		items:      make(map[string]*list.Element),
+	}
+}
+
+func (c *memoryCache) Get(key string) ([]byte, bool) {
+	c.m.Lock()
+	defer c.m.Unlock()
+	element, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(element)
+	return element.Value.(*memoryItem).value, true
+}
+
+func (c *memoryCache) Set(key string, value []byte) {
+	c.m.Lock()
+	defer c.m.Unlock()
+	if element, ok := c.items[key]; ok {
+		element.Value.(*memoryItem).value = value
+		c.order.MoveToFront(element)
+		return
+	}
+	c.items[key] = c.order.PushFront(&memoryItem{key: key, value: value})
+	if c.maxEntries > 0 && c.order.Len() > c.maxEntries {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.items, oldest.Value.(*memoryItem).key)
+	}
+}
+
+func (c *memoryCache) Delete(key string) {
+	c.m.Lock()
+	defer c.m.Unlock()
+	if element, ok := c.items[key]; ok {
+		c.order.Remove(element)
+		delete(c.items, key)
+	}
+}
+
+type diskCache struct {
+	dir string
+}
+
+// NewDiskCache stores one file per URL in dir
+func NewDiskCache(dir string) (CacheStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &diskCache{dir: dir}, nil
+}
+
+func (c *diskCache) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:]))
+}
+
+func (c *diskCache) Get(key string) ([]byte, bool) {
+	data, err := os.ReadFile(c.path(key))
+	if err != nil {
+		return nil, false
+	}
+	return data, true
+}
+
+// Set writes through a temporary file so readers never see a partial entry
+func (c *diskCache) Set(key string, value []byte) {
+	file, err := os.CreateTemp(c.dir, "tmp-*")
+	if err != nil {
+		return
+	}
+	_, err = file.Write(value)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(file.Name())
+		return
+	}
+	if err := os.Rename(file.Name(), c.path(key)); err != nil {
+		_ = os.Remove(file.Name())
+	}
+}
+
+func (c *diskCache) Delete(key string) {
+	_ = os.Remove(c.path(key))
+}
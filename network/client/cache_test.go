@@ -0,0 +1,91 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sunls24/gox/types"
+)
+
+func TestCache(t *testing.T) {
+	var hits, notModified int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		w.Header().Set("Cache-Control", "max-age=60")
+		w.Header().Set("ETag", `"v1"`)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			notModified++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		_, _ = w.Write([]byte("rates"))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	for _, store := range []func() CacheStore{
+		func() CacheStore { return NewMemoryCache(10) },
+		func() CacheStore {
+			store, err := NewDiskCache(dir)
+			if err != nil {
+				t.Fatalf("NewDiskCache() error = %v", err)
+			}
+			return store
+		},
+	} {
+		hits, notModified = 0, 0
+		c := New(WithCache(store()))
+		ctx := context.Background()
+		for range 3 {
+			body, err := c.Get(ctx, server.URL)
+			if err != nil || string(body) != "rates" {
+				t.Fatalf("Get() = %q, %v", body, err)
+			}
+		}
+		if hits != 1 {
+			t.Fatalf("hits = %d, want 1", hits)
+		}
+		body, err := c.Get(ctx, server.URL, types.NewPair("Cache-Control", "no-cache"))
+		if err != nil || string(body) != "rates" {
+			t.Fatalf("revalidated Get() = %q, %v", body, err)
+		}
+		if hits != 2 || notModified != 1 {
+			t.Fatalf("hits = %d, not modified = %d, want 2 and 1", hits, notModified)
+		}
+	}
+}
+
+func TestCacheAuthorization(t *testing.T) {
+	var hits int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		w.Header().Set("Cache-Control", "max-age=60")
+		if r.URL.Path == "/public" {
+			w.Header().Set("Cache-Control", "public, max-age=60")
+		}
+		_, _ = w.Write([]byte(r.Header.Get("Authorization")))
+	}))
+	defer server.Close()
+
+	c := New(WithCache(NewMemoryCache(10)))
+	ctx := context.Background()
+	for _, account := range []string{"a", "b", "a"} {
+		body, err := c.Get(ctx, server.URL, types.NewPair("Authorization", account))
+		if err != nil || string(body) != account {
+			t.Fatalf("Get() as %s = %q, %v", account, body, err)
+		}
+	}
+	if hits != 3 {
+		t.Fatalf("hits = %d, want 3 for private responses", hits)
+	}
+	for _, account := range []string{"a", "b"} {
+		if _, err := c.Get(ctx, server.URL+"/public", types.NewPair("Authorization", account)); err != nil {
+			t.Fatalf("Get() error = %v", err)
+		}
+	}
+	if hits != 4 {
+		t.Fatalf("hits = %d, want public response served from cache", hits)
+	}
+}
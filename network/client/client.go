@@ -3,11 +3,28 @@ package client
 import (
 	"net/http"
 	"net/http/cookiejar"
+	"net/url"
+	"strings"
 	"time"
+
+	"github.com/sunls24/gox/types"
 )
 
 type Client struct {
-	client *http.Client
+	client      *http.Client
+	retry       *Retry
+	middlewares []Middleware
+	limiter     *rateLimiter
+	breakers    *breakers
+	proxy       *url.URL
+	proxies     *ProxyPool
+	proxyErr    error
+	baseURL     string
+	header      []types.Pair[string]
+	rawBody     bool
+	compressMin int64
+	har         *HAR
+	maxResponse int64
 }
 
 type Option func(*Client)
@@ -20,6 +37,26 @@ func WithClient(client *http.Client) Option {
 	}
 }
 
+func WithTransport(transport http.RoundTripper) Option {
+	return func(c *Client) {
+		c.client.Transport = transport
+	}
+}
+
+// WithBaseURL requests with a relative URL are joined to baseURL, keeping its path
+func WithBaseURL(baseURL string) Option {
+	return func(c *Client) {
+		c.baseURL = strings.TrimRight(strings.TrimSpace(baseURL), "/")
+	}
+}
+
+// WithHeader default headers, headers already on the request or passed per call win
+func WithHeader(header ...types.Pair[string]) Option {
+	return func(c *Client) {
+		c.header = append(c.header, header...)
+	}
+}
+
 func WithJar() Option {
 	return func(c *Client) {
 		c.client.Jar, _ = cookiejar.New(nil)
@@ -36,6 +73,8 @@ func New(opts ...Option) *Client {
 	for _, opt := range opts {
 		opt(c)
 	}
+	// the proxy must survive WithClient and WithTransport given after it
+	c.applyProxy()
 
 	return c
 }
@@ -0,0 +1,170 @@
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+)
+
+const acceptEncoding = "gzip, deflate, br, zstd"
+
+// WithoutDecompression returns bodies as received when the request sets
+// Accept-Encoding itself. Without one the client adds nothing, but the
+// http.Transport still asks for gzip and decodes it transparently.
+func WithoutDecompression() Option {
+	return func(c *Client) {
+		c.rawBody = true
+	}
+}
+
+// WithRequestCompression gzips request bodies of at least minSize bytes, only
+// bodies with a known length that can be replayed are compressed
+func WithRequestCompression(minSize int64) Option {
+	return func(c *Client) {
+		c.compressMin = max(minSize, 1)
+	}
+}
+
+func compressRequest(req *http.Request, minSize int64) error {
+	if req.GetBody == nil || req.ContentLength < minSize || req.Header.Get("Content-Encoding") != "" {
+		return nil
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	if _, err := io.Copy(writer, body); err != nil {
+		return err
+	}
+	if err := writer.Close(); err != nil {
+		return err
+	}
+	data := buf.Bytes()
+	req.Body = io.NopCloser(bytes.NewReader(data))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(data)), nil
+	}
+	req.ContentLength = int64(len(data))
+	req.Header.Set("Content-Encoding", "gzip")
+	return nil
+}
+
+// decompressResponse decodes every known Content-Encoding, in reverse order of
+// application, unknown codings leave the body as is
+func decompressResponse(resp *http.Response) {
+	if resp.Request != nil && resp.Request.Method == http.MethodHead ||
+		resp.StatusCode == http.StatusNoContent || resp.StatusCode == http.StatusNotModified {
+		return
+	}
+	var codings []string
+	for _, value := range resp.Header.Values("Content-Encoding") {
+		for _, coding := range strings.Split(value, ",") {
+			if coding = strings.ToLower(strings.TrimSpace(coding)); coding != "" && coding != "identity" {
+				codings = append(codings, coding)
+			}
+		}
+	}
+	if len(codings) == 0 {
+		return
+	}
+	for _, coding := range codings {
+		switch coding {
+		case "gzip", "x-gzip", "deflate", "br", "zstd":
+		default:
+			return
+		}
+	}
+	body := resp.Body
+	var reader io.Reader = body
+	for i := len(codings) - 1; i >= 0; i-- {
+		reader = &lazyDecoder{coding: codings[i], source: reader}
+	}
+	resp.Body = &decodedBody{Reader: reader, body: body}
+	resp.Header.Del("Content-Encoding")
+	resp.Header.Del("Content-Length")
+	resp.ContentLength = -1
+	resp.Uncompressed = true
+}
+
+// lazyDecoder creates the decoder on first read so empty bodies do not fail
+type lazyDecoder struct {
+	coding string
+	source io.Reader
+	reader io.Reader
+	closer func()
+	err    error
+}
+
+func (d *lazyDecoder) Read(p []byte) (int, error) {
+	if d.reader == nil && d.err == nil {
+		d.reader, d.closer, d.err = newDecoder(d.coding, d.source)
+	}
+	if d.err != nil {
+		return 0, d.err
+	}
+	return d.reader.Read(p)
+}
+
+func (d *lazyDecoder) close() {
+	if d.closer != nil {
+		d.closer()
+	}
+	if inner, ok := d.source.(*lazyDecoder); ok {
+		inner.close()
+	}
+}
+
+func newDecoder(coding string, source io.Reader) (io.Reader, func(), error) {
+	switch coding {
+	case "gzip", "x-gzip":
+		reader, err := gzip.NewReader(source)
+		if err != nil {
+			return nil, nil, err
+		}
+		return reader, func() { _ = reader.Close() }, nil
+	case "deflate":
+		// deflate should be zlib wrapped, some servers send raw deflate
+		buffered := bufio.NewReader(source)
+		if header, err := buffered.Peek(2); err == nil && header[0]&0x0f == 8 && (uint16(header[0])<<8|uint16(header[1]))%31 == 0 {
+			reader, err := zlib.NewReader(buffered)
+			if err != nil {
+				return nil, nil, err
+			}
+			return reader, func() { _ = reader.Close() }, nil
+		}
+		reader := flate.NewReader(buffered)
+		return reader, func() { _ = reader.Close() }, nil
+	case "br":
+		return brotli.NewReader(source), nil, nil
+	case "zstd":
+		reader, err := zstd.NewReader(source, zstd.WithDecoderConcurrency(1))
+		if err != nil {
+			return nil, nil, err
+		}
+		return reader, reader.Close, nil
+	}
+	return source, nil, nil
+}
+
+type decodedBody struct {
+	io.Reader
+	body io.ReadCloser
+}
+
+func (b *decodedBody) Close() error {
+	if d, ok := b.Reader.(*lazyDecoder); ok {
+		d.close()
+	}
+	return b.body.Close()
+}
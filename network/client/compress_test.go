@@ -0,0 +1,76 @@
+package client
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+)
+
+func TestDecompression(t *testing.T) {
+	const content = "hello compressed world"
+	encoders := map[string]func(io.Writer) io.WriteCloser{
+		"gzip":    func(w io.Writer) io.WriteCloser { return gzip.NewWriter(w) },
+		"deflate": func(w io.Writer) io.WriteCloser { return zlib.NewWriter(w) },
+		"br":      func(w io.Writer) io.WriteCloser { return brotli.NewWriter(w) },
+		"zstd": func(w io.Writer) io.WriteCloser {
+			encoder, _ := zstd.NewWriter(w)
+			return encoder
+		},
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		coding := strings.TrimPrefix(r.URL.Path, "/")
+		w.Header().Set("Content-Encoding", coding)
+		encoder := encoders[coding](w)
+		_, _ = encoder.Write([]byte(content))
+		_ = encoder.Close()
+	}))
+	defer server.Close()
+
+	c := New()
+	for coding := range encoders {
+		body, err := c.Get(context.Background(), server.URL+"/"+coding)
+		if err != nil {
+			t.Fatalf("%s: Get() error = %v", coding, err)
+		}
+		if string(body) != content {
+			t.Fatalf("%s: body = %q, want %q", coding, body, content)
+		}
+	}
+}
+
+func TestRequestCompression(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Encoding") != "gzip" {
+			_, _ = io.Copy(w, r.Body)
+			return
+		}
+		reader, err := gzip.NewReader(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		_, _ = io.Copy(w, reader)
+	}))
+	defer server.Close()
+
+	large := strings.Repeat("a", 2048)
+	c := New(WithRequestCompression(1024))
+	for _, text := range []string{"small", large} {
+		body, err := c.Post(context.Background(), server.URL, strings.NewReader(text))
+		if err != nil {
+			t.Fatalf("Post() error = %v", err)
+		}
+		if !bytes.Equal(body, []byte(text)) {
+			t.Fatalf("body length = %d, want %d", len(body), len(text))
+		}
+	}
+}
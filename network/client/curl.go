@@ -0,0 +1,407 @@
+package client
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/sunls24/gox/network/header"
+	"github.com/sunls24/gox/network/internal/reqbody"
+	"github.com/sunls24/gox/types"
+)
+
+// curlFlags options without a value that do not change the request
+var curlFlags = []string{
+	"-s", "--silent", "-S", "--show-error", "-k", "--insecure", "-L", "--location",
+	"-i", "--include", "-v", "--verbose", "-f", "--fail", "-g", "--globoff",
+	"--http1.1", "--http2", "--no-progress-meter",
+}
+
+// curlIgnored options with a value that do not change the request
+var curlIgnored = []string{
+	"-m", "--max-time", "--connect-timeout", "-o", "--output", "--retry", "-w", "--write-out",
+}
+
+// ParseCurl turns a curl command line, as copied from browser devtools, into
+// a request. The headers are also returned as pairs in command line order, pass
+// them to Do to keep that order. Supported options are -H, -b, -d, --data,
+// --data-raw, --data-binary, -X, -u, -A, -e, -I, --url and --compressed,
+// a data value starting with @ is read from the file, except with --data-raw.
+func ParseCurl(ctx context.Context, command string) (*http.Request, []types.Pair[string], error) {
+	args, err := splitCommand(command)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(args) == 0 || args[0] != "curl" {
+		return nil, nil, errors.New("curl: command must start with curl")
+	}
+
+	var (
+		method, rawURL string
+		data           []string
+		hasData        bool
+		pairs          []types.Pair[string]
+	)
+	for i := 1; i < len(args); i++ {
+		name, value, attached := curlOption(args[i])
+		if name == "" {
+			if rawURL != "" {
+				return nil, nil, fmt.Errorf("curl: unexpected argument %q", args[i])
+			}
+			rawURL = args[i]
+			continue
+		}
+		if slices.Contains(curlFlags, name) {
+			continue
+		}
+		if name == "--compressed" {
+			pairs = append(pairs, types.NewPair("Accept-Encoding", acceptEncoding))
+			continue
+		}
+		if name == "-I" || name == "--head" {
+			method = http.MethodHead
+			continue
+		}
+		if !attached {
+			if i+1 >= len(args) {
+				return nil, nil, fmt.Errorf("curl: option %s needs a value", name)
+			}
+			i++
+			value = args[i]
+		}
+		switch name {
+		case "-H", "--header":
+			key, val, ok := strings.Cut(value, ":")
+			if !ok {
+				return nil, nil, fmt.Errorf("curl: invalid header %q", value)
+			}
+			pairs = append(pairs, types.NewPair(strings.TrimSpace(key), strings.TrimSpace(val)))
+		case "-b", "--cookie":
+			if !strings.Contains(value, "=") {
+				return nil, nil, fmt.Errorf("curl: cookie file %q is not supported", value)
+			}
+			pairs = append(pairs, types.NewPair("Cookie", value))
+		case "-d", "--data", "--data-ascii", "--data-binary", "--data-raw":
+			if name != "--data-raw" && strings.HasPrefix(value, "@") {
+				content, err := os.ReadFile(value[1:])
+				if err != nil {
+					return nil, nil, fmt.Errorf("curl: read data: %w", err)
+				}
+				value = string(content)
+				if name != "--data-binary" {
+					value = strings.NewReplacer("\r", "", "\n", "").Replace(value)
+				}
+			}
+			data = append(data, value)
+			hasData = true
+		case "-X", "--request":
+			method = strings.ToUpper(value)
+		case "-u", "--user":
+			if !strings.Contains(value, ":") {
+				value += ":"
+			}
+			pairs = append(pairs, types.NewPair("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(value))))
+		case "-A", "--user-agent":
+			pairs = append(pairs, types.NewPair("User-Agent", value))
+		case "-e", "--referer":
+			pairs = append(pairs, types.NewPair("Referer", value))
+		case "--url":
+			rawURL = value
+		default:
+			if !slices.Contains(curlIgnored, name) {
+				return nil, nil, fmt.Errorf("curl: unsupported option %s", name)
+			}
+		}
+	}
+	if rawURL == "" {
+		return nil, nil, errors.New("curl: missing url")
+	}
+	if !strings.Contains(rawURL, "://") {
+		rawURL = "http://" + rawURL
+	}
+
+	var body io.Reader
+	if hasData {
+		body = strings.NewReader(strings.Join(data, "&"))
+		if !slices.ContainsFunc(pairs, func(p types.Pair[string]) bool { return strings.EqualFold(p.Key, "Content-Type") }) {
+			pairs = append(pairs, types.NewPair("Content-Type", "application/x-www-form-urlencoded"))
+		}
+	}
+	if method == "" {
+		method = http.MethodGet
+		if hasData {
+			method = http.MethodPost
+		}
+	}
+	req, err := http.NewRequestWithContext(ctx, method, rawURL, body)
+	if err != nil {
+		return nil, nil, fmt.Errorf("curl: %w", err)
+	}
+	for _, p := range pairs {
+		req.Header.Add(p.Key, p.Value)
+	}
+	return req, pairs, nil
+}
+
+// curlOption splits -XPOST and --request=POST into name and value, name is
+// empty for arguments that are not options
+func curlOption(arg string) (string, string, bool) {
+	switch {
+	case strings.HasPrefix(arg, "--"):
+		name, value, ok := strings.Cut(arg, "=")
+		return name, value, ok
+	case len(arg) > 2 && arg[0] == '-':
+		if strings.Trim(arg[1:], "sSkLivfg") == "" {
+			// combined flags such as -sSL
+			return "-s", "", false
+		}
+		return arg[:2], arg[2:], true
+	case len(arg) == 2 && arg[0] == '-':
+		return arg, "", false
+	}
+	return "", "", false
+}
+
+// splitCommand splits a POSIX shell command line, supporting single and double
+// quotes, $'...' strings, backslash escapes and line continuations
+func splitCommand(command string) ([]string, error) {
+	var (
+		args    []string
+		current strings.Builder
+		inArg   bool
+	)
+	for i := 0; i < len(command); i++ {
+		ch := command[i]
+		switch {
+		case ch == '\\' && i+1 < len(command):
+			i++
+			if command[i] == '\n' || command[i] == '\r' {
+				if command[i] == '\r' && i+1 < len(command) && command[i+1] == '\n' {
+					i++
+				}
+				continue
+			}
+			current.WriteByte(command[i])
+			inArg = true
+		case ch == '\'':
+			end := strings.IndexByte(command[i+1:], '\'')
+			if end < 0 {
+				return nil, errors.New("curl: unterminated single quote")
+			}
+			current.WriteString(command[i+1 : i+1+end])
+			i += end + 1
+			inArg = true
+		case ch == '$' && i+1 < len(command) && command[i+1] == '\'':
+			n, err := ansiQuoted(&current, command[i+2:])
+			if err != nil {
+				return nil, err
+			}
+			i += n + 1
+			inArg = true
+		case ch == '"':
+			i++
+			for ; i < len(command) && command[i] != '"'; i++ {
+				if command[i] == '\\' && i+1 < len(command) && strings.IndexByte("\"\\$`\n", command[i+1]) >= 0 {
+					i++
+					if command[i] == '\n' {
+						continue
+					}
+				}
+				current.WriteByte(command[i])
+			}
+			if i >= len(command) {
+				return nil, errors.New("curl: unterminated double quote")
+			}
+			inArg = true
+		case ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r':
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+		default:
+			current.WriteByte(ch)
+			inArg = true
+		}
+	}
+	if inArg {
+		args = append(args, current.String())
+	}
+	return args, nil
+}
+
+// ansiQuoted decodes the body of a $'...' string, s starts after the opening
+// quote, returns the number of bytes consumed including the closing quote
+func ansiQuoted(w *strings.Builder, s string) (int, error) {
+	escapes := map[byte]byte{'n': '\n', 'r': '\r', 't': '\t', '\\': '\\', '\'': '\'', '"': '"', '?': '?', 'a': '\a', 'b': '\b', 'e': 0x1b, 'f': '\f', 'v': '\v'}
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\'':
+			return i + 1, nil
+		case s[i] == '\\' && i+1 < len(s):
+			i++
+			if b, ok := escapes[s[i]]; ok {
+				w.WriteByte(b)
+				continue
+			}
+			if s[i] == 'x' || s[i] == 'u' || s[i] == 'U' {
+				size := map[byte]int{'x': 2, 'u': 4, 'U': 8}[s[i]]
+				j := i + 1
+				var r rune
+				for ; j < len(s) && j <= i+size && isHex(s[j]); j++ {
+					r = r<<4 | rune(unhex(s[j]))
+				}
+				if j == i+1 {
+					w.WriteByte('\\')
+					w.WriteByte(s[i])
+					continue
+				}
+				if s[i] == 'x' {
+					w.WriteByte(byte(r))
+				} else {
+					w.WriteRune(r)
+				}
+				i = j - 1
+				continue
+			}
+			w.WriteByte('\\')
+			w.WriteByte(s[i])
+		default:
+			w.WriteByte(s[i])
+		}
+	}
+	return 0, errors.New("curl: unterminated $' quote")
+}
+
+func isHex(b byte) bool {
+	return '0' <= b && b <= '9' || 'a' <= b && b <= 'f' || 'A' <= b && b <= 'F'
+}
+
+func unhex(b byte) byte {
+	switch {
+	case b <= '9':
+		return b - '0'
+	case b <= 'F':
+		return b - 'A' + 10
+	}
+	return b - 'a' + 10
+}
+
+// DefaultCurlRedact headers whose values Curl replaces with REDACTED
+var DefaultCurlRedact = []string{"Authorization", "Proxy-Authorization", "Cookie"}
+
+type curlConfig struct {
+	redact []string
+}
+
+type CurlOption func(*curlConfig)
+
+// CurlRedact replaces DefaultCurlRedact, no headers turns redaction off and
+// keeps the password in the URL
+func CurlRedact(headers ...string) CurlOption {
+	return func(c *curlConfig) {
+		c.redact = headers
+	}
+}
+
+// Curl renders req as a curl command for logging, headers follow the order
+// stored by header.ContextWithOrder, then sorted. Credentials are redacted, see
+// CurlRedact. The body is read without consuming it.
+func Curl(req *http.Request, opts ...CurlOption) (string, error) {
+	config := curlConfig{redact: DefaultCurlRedact}
+	for _, opt := range opts {
+		opt(&config)
+	}
+	var b strings.Builder
+	b.WriteString("curl")
+	method := req.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+	body, err := reqbody.Read(req)
+	if err != nil {
+		return "", err
+	}
+	switch {
+	case method == http.MethodHead:
+		// -X HEAD makes curl wait for a body that never comes
+		b.WriteString(" -I")
+	case method != http.MethodGet && !(method == http.MethodPost && len(body) > 0):
+		b.WriteString(" -X " + shellQuote(method))
+	}
+	if len(config.redact) > 0 {
+		b.WriteString(" " + shellQuote(req.URL.Redacted()))
+	} else {
+		b.WriteString(" " + shellQuote(req.URL.String()))
+	}
+
+	var keys []string
+	if order, ok := header.OrderFromContext(req.Context()); ok {
+		keys = slices.Clone(order.Headers)
+	}
+	var rest []string
+	for key := range req.Header {
+		if !slices.Contains(keys, key) {
+			rest = append(rest, key)
+		}
+	}
+	slices.Sort(rest)
+	if req.Host != "" && req.Host != req.URL.Host {
+		b.WriteString(" -H " + shellQuote("Host: "+req.Host))
+	}
+	for _, key := range append(keys, rest...) {
+		redact := slices.ContainsFunc(config.redact, func(name string) bool { return strings.EqualFold(name, key) })
+		for _, value := range req.Header[key] {
+			if redact {
+				value = "REDACTED"
+			}
+			b.WriteString(" -H " + shellQuote(key+": "+value))
+		}
+	}
+	if len(body) > 0 {
+		b.WriteString(" --data-raw " + shellQuote(string(body)))
+	}
+	return b.String(), nil
+}
+
+// shellQuote quotes s for a POSIX shell, values that are safe as is stay bare.
+// Control characters and bytes that are not UTF-8 are escaped as \xNN in a
+// $'...' string, so binary bodies survive.
+func shellQuote(s string) string {
+	if s != "" && strings.Trim(s, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-_./:=@%+,") == "" {
+		return s
+	}
+	if utf8.ValidString(s) && strings.IndexFunc(s, func(r rune) bool { return r < 0x20 || r == 0x7f }) < 0 {
+		return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+	}
+	var b strings.Builder
+	b.WriteString("$'")
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		switch {
+		case r == '\\' || r == '\'':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r == '\n':
+			b.WriteString(`\n`)
+		case r == '\r':
+			b.WriteString(`\r`)
+		case r == '\t':
+			b.WriteString(`\t`)
+		case r < 0x20 || r == 0x7f || r == utf8.RuneError && size == 1:
+			fmt.Fprintf(&b, `\x%02x`, s[i])
+		default:
+			b.WriteString(s[i : i+size])
+		}
+		i += size
+	}
+	b.WriteByte('\'')
+	return b.String()
+}
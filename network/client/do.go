@@ -2,12 +2,16 @@ package client
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"slices"
+	"strings"
 
+	headerpkg "github.com/sunls24/gox/network/header"
 	"github.com/sunls24/gox/types"
 )
 
@@ -16,6 +20,14 @@ func Close(reader io.ReadCloser) {
 	_ = reader.Close()
 }
 
+// closeBody closes the body of a request that returns before http.Client.Do,
+// which would have closed it
+func closeBody(req *http.Request) {
+	if req.Body != nil {
+		_ = req.Body.Close()
+	}
+}
+
 func (c *Client) Do(req *http.Request, header ...types.Pair[string]) ([]byte, error) {
 	reader, err := c.DoReader(req, header...)
 	if err != nil {
@@ -31,39 +43,124 @@ func Do(req *http.Request, header ...types.Pair[string]) ([]byte, error) {
 
 // DoReader reader need close
 func (c *Client) DoReader(req *http.Request, header ...types.Pair[string]) (io.ReadCloser, error) {
+	resp, err := c.do(req, header...)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+func DoReader(req *http.Request, header ...types.Pair[string]) (io.ReadCloser, error) {
+	return def.DoReader(req, header...)
+}
+
+// do returns a response with 2xx status, body need close
+func (c *Client) do(req *http.Request, header ...types.Pair[string]) (*http.Response, error) {
+	if err := c.resolve(req); err != nil {
+		closeBody(req)
+		return nil, err
+	}
+	for _, p := range c.header {
+		if _, ok := req.Header[http.CanonicalHeaderKey(p.Key)]; !ok {
+			req.Header.Set(p.Key, p.Value)
+		}
+	}
+	// per call headers replace existing ones, repeated pairs are all sent
 	for _, p := range header {
-		req.Header.Set(p.Key, p.Value)
+		req.Header.Del(p.Key)
+	}
+	for _, p := range header {
+		req.Header.Add(p.Key, p.Value)
+	}
+	if _, ok := headerpkg.OrderFromContext(req.Context()); !ok && len(c.header)+len(header) > 0 {
+		order := headerpkg.Order{Headers: headerpkg.Keys(append(slices.Clone(c.header), header...)...)}
+		req = req.WithContext(headerpkg.ContextWithOrder(req.Context(), order))
+	}
+	if c.compressMin > 0 {
+		if err := compressRequest(req, c.compressMin); err != nil {
+			closeBody(req)
+			return nil, err
+		}
 	}
-	resp, err := c.client.Do(req)
+	resp, err := c.send(req)
 	if err != nil {
 		return nil, err
 	}
-	if !statusOK(resp.StatusCode) {
-		defer Close(resp.Body)
-		body, err := io.ReadAll(resp.Body)
-		if err != nil {
+	limit := c.responseLimit(req.Context())
+	if limit > 0 {
+		if err := limitResponse(resp, limit); err != nil {
 			return nil, err
 		}
-		if len(body) != 0 {
-			return nil, fmt.Errorf("%s: %s", resp.Status, string(body))
-		}
-		return nil, errors.New(resp.Status)
 	}
-	return resp.Body, nil
+	if !statusOK(resp.StatusCode) {
+		return nil, newStatusError(resp, limit)
+	}
+	return resp, nil
 }
 
-func DoReader(req *http.Request, header ...types.Pair[string]) (io.ReadCloser, error) {
-	return def.DoReader(req, header...)
+func (c *Client) resolve(req *http.Request) error {
+	if c.baseURL == "" || req.URL.IsAbs() || req.URL.Host != "" {
+		return nil
+	}
+	base, err := url.Parse(c.baseURL)
+	if err != nil {
+		return fmt.Errorf("parse base url: %w", err)
+	}
+	u := *base
+	u.Path = strings.TrimRight(base.Path, "/") + "/" + strings.TrimLeft(req.URL.Path, "/")
+	// RawPath keeps encoded slashes such as a%2Fb
+	u.RawPath = strings.TrimRight(base.EscapedPath(), "/") + "/" + strings.TrimLeft(req.URL.EscapedPath(), "/")
+	query := base.Query()
+	for k, v := range req.URL.Query() {
+		query[k] = v
+	}
+	u.RawQuery = query.Encode()
+	u.Fragment = req.URL.Fragment
+	req.URL = &u
+	req.Host = u.Host
+	return nil
 }
 
 func statusOK(code int) bool {
 	return http.StatusOK <= code && code < http.StatusMultipleChoices
 }
 
+// NewBody url.Values is form-encoded, io.Reader is used as is, others are JSON-encoded
 func NewBody(body any) io.Reader {
-	if body == nil {
+	switch v := body.(type) {
+	case nil:
 		return nil
+	case url.Values:
+		return strings.NewReader(v.Encode())
+	case io.Reader:
+		return v
 	}
 	data, _ := json.Marshal(body)
 	return bytes.NewReader(data)
 }
+
+// NewRequest body see NewBody, Content-Type is set to match it
+func NewRequest(ctx context.Context, method, url string, body any) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, NewBody(body))
+	if err != nil {
+		return nil, err
+	}
+	if contentType := bodyContentType(body); contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	return req, nil
+}
+
+func bodyContentType(body any) string {
+	switch v := body.(type) {
+	case nil:
+		return ""
+	case url.Values:
+		return "application/x-www-form-urlencoded"
+	case Body:
+		return v.ContentType()
+	case io.Reader:
+		return ""
+	}
+	return "application/json"
+}
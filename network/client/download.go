@@ -0,0 +1,202 @@
+package client
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/sunls24/gox/types"
+)
+
+type DownloadConfig struct {
+	// Checksum hex digest of the complete file, empty skips verification
+	Checksum string
+	// Hash defaults to sha256
+	Hash hash.Hash
+	// Progress total is -1 when the server does not report a length
+	Progress func(written, total int64)
+}
+
+// Download writes url to path through path.part, resuming the partial file with
+// Range and If-Range when the server sent a validator for it. path is only
+// replaced after the download completes and the checksum matches. The client
+// WithMaxResponseSize does not apply, set one with ContextWithMaxResponseSize.
+// Accept-Encoding is identity, so every attempt gets the same variant and its
+// validator.
+func (c *Client) Download(ctx context.Context, url, path string, config DownloadConfig, header ...types.Pair[string]) error {
+	if config.Checksum != "" && config.Hash == nil {
+		config.Hash = sha256.New()
+	}
+	ctx = withoutResponseLimit(ctx)
+	partial, meta := path+".part", path+".part.meta"
+	offset, validator := partialState(partial, meta)
+
+	req, err := NewRequest(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept-Encoding", "identity")
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+		req.Header.Set("If-Range", validator)
+	}
+	resp, err := c.do(req, header...)
+	if err != nil {
+		var statusErr *StatusError
+		if offset > 0 && errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusRequestedRangeNotSatisfiable {
+			if completeLength(statusErr.Header.Get("Content-Range")) == offset {
+				return finishDownload(partial, meta, path, config)
+			}
+			removePartial(partial, meta)
+		}
+		return err
+	}
+	defer Close(resp.Body)
+
+	if resp.StatusCode != http.StatusPartialContent || rangeStart(resp.Header.Get("Content-Range")) != offset {
+		offset = 0
+	}
+	file, err := os.OpenFile(partial, os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	if err := file.Truncate(offset); err != nil {
+		return err
+	}
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		return err
+	}
+	if validator = responseValidator(resp.Header); validator != "" {
+		if err := os.WriteFile(meta, []byte(validator), 0o644); err != nil {
+			return err
+		}
+	} else {
+		_ = os.Remove(meta)
+	}
+
+	total := int64(-1)
+	if resp.ContentLength >= 0 {
+		total = offset + resp.ContentLength
+	}
+	writer := io.Writer(file)
+	if config.Progress != nil {
+		writer = &progressWriter{w: file, written: offset, total: total, fn: config.Progress}
+		config.Progress(offset, total)
+	}
+	if _, err := io.Copy(writer, resp.Body); err != nil {
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	return finishDownload(partial, meta, path, config)
+}
+
+func Download(ctx context.Context, url, path string, config DownloadConfig, header ...types.Pair[string]) error {
+	return def.Download(ctx, url, path, config, header...)
+}
+
+func finishDownload(partial, meta, path string, config DownloadConfig) error {
+	if config.Checksum != "" {
+		file, err := os.Open(partial)
+		if err != nil {
+			return err
+		}
+		config.Hash.Reset()
+		_, err = io.Copy(config.Hash, file)
+		_ = file.Close()
+		if err != nil {
+			return err
+		}
+		if sum := hex.EncodeToString(config.Hash.Sum(nil)); !strings.EqualFold(sum, config.Checksum) {
+			removePartial(partial, meta)
+			return fmt.Errorf("download checksum mismatch: got %s, want %s", sum, config.Checksum)
+		}
+	}
+	if err := os.Rename(partial, path); err != nil {
+		return err
+	}
+	_ = os.Remove(meta)
+	return nil
+}
+
+func partialState(partial, meta string) (int64, string) {
+	info, err := os.Stat(partial)
+	if err != nil || info.Size() == 0 {
+		return 0, ""
+	}
+	validator, err := os.ReadFile(meta)
+	if err != nil || len(validator) == 0 {
+		return 0, ""
+	}
+	return info.Size(), string(validator)
+}
+
+func removePartial(partial, meta string) {
+	_ = os.Remove(partial)
+	_ = os.Remove(meta)
+}
+
+// responseValidator weak ETags cannot be used with If-Range
+func responseValidator(header http.Header) string {
+	if etag := header.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+		return etag
+	}
+	return header.Get("Last-Modified")
+}
+
+// rangeStart parses the first byte position of "bytes 100-199/200"
+func rangeStart(contentRange string) int64 {
+	value, ok := strings.CutPrefix(contentRange, "bytes ")
+	if !ok {
+		return -1
+	}
+	start, _, ok := strings.Cut(value, "-")
+	if !ok {
+		return -1
+	}
+	n, err := strconv.ParseInt(start, 10, 64)
+	if err != nil {
+		return -1
+	}
+	return n
+}
+
+// completeLength parses the complete length of "bytes */200"
+func completeLength(contentRange string) int64 {
+	_, length, ok := strings.Cut(contentRange, "/")
+	if !ok {
+		return -1
+	}
+	n, err := strconv.ParseInt(length, 10, 64)
+	if err != nil {
+		return -1
+	}
+	return n
+}
+
+type progressWriter struct {
+	w       io.Writer
+	written int64
+	total   int64
+	fn      func(written, total int64)
+}
+
+func (p *progressWriter) Write(b []byte) (int, error) {
+	n, err := p.w.Write(b)
+	p.written += int64(n)
+	p.fn(p.written, p.total)
+	return n, err
+}
@@ -0,0 +1,62 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDownloadResume(t *testing.T) {
+	content := strings.Repeat("0123456789", 100)
+	var ranges, encodings []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ranges = append(ranges, r.Header.Get("Range"))
+		encodings = append(encodings, r.Header.Get("Accept-Encoding"))
+		w.Header().Set("ETag", `"v1"`)
+		http.ServeContent(w, r, "", time.Time{}, strings.NewReader(content))
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(path+".part", []byte(content[:300]), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path+".part.meta", []byte(`"v1"`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256([]byte(content))
+	var last int64
+	err := New().Download(context.Background(), server.URL, path, DownloadConfig{
+		Checksum: hex.EncodeToString(sum[:]),
+		Progress: func(written, total int64) {
+			last = written
+		},
+	})
+	if err != nil {
+		t.Fatalf("Download() error = %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, []byte(content)) {
+		t.Fatalf("content length = %d, want %d", len(data), len(content))
+	}
+	if len(ranges) != 1 || ranges[0] != "bytes=300-" || last != int64(len(content)) {
+		t.Fatalf("ranges = %v, progress = %d", ranges, last)
+	}
+	if encodings[0] != "identity" {
+		t.Fatalf("Accept-Encoding = %q, want identity", encodings[0])
+	}
+	if _, err := os.Stat(path + ".part"); !os.IsNotExist(err) {
+		t.Fatalf("partial file still exists: %v", err)
+	}
+}
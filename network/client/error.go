@@ -0,0 +1,43 @@
+package client
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+)
+
+const maxErrorBody = 64 << 10
+
+type StatusError struct {
+	StatusCode int
+	Status     string
+	Header     http.Header
+	// Body at most 64KB of the response body
+	Body []byte
+}
+
+func (e *StatusError) Error() string {
+	if len(e.Body) != 0 {
+		return fmt.Sprintf("%s: %s", e.Status, string(e.Body))
+	}
+	return e.Status
+}
+
+// newStatusError limit caps the body below maxErrorBody when positive
+func newStatusError(resp *http.Response, limit int64) error {
+	defer Close(resp.Body)
+	size := int64(maxErrorBody)
+	if limit > 0 {
+		size = min(size, limit)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, size))
+	if err != nil {
+		return err
+	}
+	return &StatusError{
+		StatusCode: resp.StatusCode,
+		Status:     resp.Status,
+		Header:     resp.Header,
+		Body:       body,
+	}
+}
@@ -0,0 +1,43 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestStatusError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Error", "quota")
+		if r.URL.Path == "/large" {
+			w.WriteHeader(http.StatusInternalServerError)
+			_, _ = w.Write([]byte(strings.Repeat("x", maxErrorBody+100)))
+			return
+		}
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	_, err := Get(context.Background(), server.URL)
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusBadGateway {
+		t.Fatalf("Get() error = %v, want 502 *StatusError", err)
+	}
+	if err.Error() != "502 Bad Gateway" {
+		t.Errorf("Error() = %q, want %q", err.Error(), "502 Bad Gateway")
+	}
+	if statusErr.Header.Get("X-Error") != "quota" {
+		t.Errorf("header X-Error = %q, want quota", statusErr.Header.Get("X-Error"))
+	}
+
+	_, err = Get(context.Background(), server.URL+"/large")
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusInternalServerError {
+		t.Fatalf("Get() error = %v, want 500 *StatusError", err)
+	}
+	if len(statusErr.Body) != maxErrorBody {
+		t.Errorf("body length = %d, want %d", len(statusErr.Body), maxErrorBody)
+	}
+}
@@ -0,0 +1,415 @@
+package client
+
+import (
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptrace"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/sunls24/gox/network/header"
+)
+
+// DefaultHARRedact headers whose values HAR replaces with REDACTED, together
+// with the cookies parsed from them
+var DefaultHARRedact = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie"}
+
+// HAR records the traffic of a client as an HTTP Archive 1.2, see WithHAR
+type HAR struct {
+	// MaxEntries only the most recent entries are kept, 0 keeps all
+	MaxEntries int
+	// Redact empty records every header in clear
+	Redact []string
+
+	maxBody int
+	m       sync.Mutex
+	entries []*harEntry
+}
+
+// NewHAR bodies longer than maxBody bytes are truncated, 0 skips bodies.
+// MaxEntries defaults to 1000, Redact to DefaultHARRedact.
+func NewHAR(maxBody int) *HAR {
+	return &HAR{maxBody: maxBody, MaxEntries: 1000, Redact: DefaultHARRedact}
+}
+
+// Reset drops the recorded entries
+func (h *HAR) Reset() {
+	h.m.Lock()
+	defer h.m.Unlock()
+	h.entries = nil
+}
+
+// WithHAR records every attempt as it goes over the wire, request headers in
+// the order the transport wrote them, jar cookies included. Transports that do
+// not report written headers, such as OrderedTransport, are recorded with the
+// order of header.ContextWithOrder. Response content is stored decoded.
+func WithHAR(har *HAR) Option {
+	return func(c *Client) {
+		c.har = har
+	}
+}
+
+type harLog struct {
+	Log struct {
+		Version string      `json:"version"`
+		Creator harCreator  `json:"creator"`
+		Entries []*harEntry `json:"entries"`
+	} `json:"log"`
+}
+
+type harCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type harEntry struct {
+	StartedDateTime time.Time   `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         harRequest  `json:"request"`
+	Response        harResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         harTimings  `json:"timings"`
+	ServerIPAddress string      `json:"serverIPAddress,omitempty"`
+	Error           string      `json:"_error,omitempty"`
+
+	trace harTrace
+	// wire request headers reported by the transport, in written order
+	wire []harNameValue
+}
+
+type harRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harNameValue `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	QueryString []harNameValue `json:"queryString"`
+	PostData    *harPostData   `json:"postData,omitempty"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int64          `json:"bodySize"`
+}
+
+type harResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harNameValue `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	Content     harContent     `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int64          `json:"bodySize"`
+}
+
+type harNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type harPostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+type harContent struct {
+	Size     int64  `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
+	Encoding string `json:"encoding,omitempty"`
+}
+
+type harTimings struct {
+	Blocked float64 `json:"blocked"`
+	DNS     float64 `json:"dns"`
+	Connect float64 `json:"connect"`
+	SSL     float64 `json:"ssl"`
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+type harTrace struct {
+	getConn, dnsStart, dnsDone, connectStart, connectDone time.Time
+	tlsStart, tlsDone, gotConn, wroteRequest, firstByte   time.Time
+}
+
+// Save writes the archive to path, it can be opened in browser devtools
+func (h *HAR) Save(path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := h.Write(file); err != nil {
+		_ = file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+func (h *HAR) Write(w io.Writer) error {
+	h.m.Lock()
+	defer h.m.Unlock()
+	var log harLog
+	log.Log.Version = "1.2"
+	log.Log.Creator = harCreator{Name: "github.com/sunls24/gox", Version: "1"}
+	log.Log.Entries = h.entries
+	if log.Log.Entries == nil {
+		log.Log.Entries = []*harEntry{}
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(log)
+}
+
+// start jar cookies are added the way http.Client adds them, the headers are
+// replaced by the written ones once the transport reports them
+func (h *HAR) start(req *http.Request, jar http.CookieJar) (*http.Request, *harEntry) {
+	sent := &http.Request{Header: req.Header.Clone()}
+	if sent.Header == nil {
+		sent.Header = http.Header{}
+	}
+	if jar != nil {
+		for _, cookie := range jar.Cookies(req.URL) {
+			sent.AddCookie(cookie)
+		}
+	}
+	var keys []string
+	if order, ok := header.OrderFromContext(req.Context()); ok {
+		keys = order.Headers
+	}
+	entry := &harEntry{
+		StartedDateTime: time.Now(),
+		Request: harRequest{
+			Method:      req.Method,
+			URL:         req.URL.String(),
+			HTTPVersion: req.Proto,
+			Cookies:     h.cookies(sent.Cookies(), "Cookie"),
+			Headers:     h.headers(sent.Header, keys),
+			QueryString: []harNameValue{},
+			HeadersSize: -1,
+			BodySize:    req.ContentLength,
+		},
+	}
+	for name, values := range req.URL.Query() {
+		for _, value := range values {
+			entry.Request.QueryString = append(entry.Request.QueryString, harNameValue{Name: name, Value: value})
+		}
+	}
+	if req.GetBody != nil && h.maxBody > 0 {
+		if body, err := req.GetBody(); err == nil {
+			data, _ := io.ReadAll(io.LimitReader(body, int64(h.maxBody)))
+			_ = body.Close()
+			text, _ := harText(data)
+			entry.Request.PostData = &harPostData{MimeType: req.Header.Get("Content-Type"), Text: text}
+		}
+	}
+
+	t := &entry.trace
+	now := func(field *time.Time) {
+		h.m.Lock()
+		*field = time.Now()
+		h.m.Unlock()
+	}
+	trace := &httptrace.ClientTrace{
+		GetConn:           func(string) { now(&t.getConn) },
+		DNSStart:          func(httptrace.DNSStartInfo) { now(&t.dnsStart) },
+		DNSDone:           func(httptrace.DNSDoneInfo) { now(&t.dnsDone) },
+		ConnectStart:      func(string, string) { now(&t.connectStart) },
+		ConnectDone:       func(string, string, error) { now(&t.connectDone) },
+		TLSHandshakeStart: func() { now(&t.tlsStart) },
+		TLSHandshakeDone:  func(tls.ConnectionState, error) { now(&t.tlsDone) },
+		GotConn: func(info httptrace.GotConnInfo) {
+			h.m.Lock()
+			t.gotConn = time.Now()
+			if addr := info.Conn.RemoteAddr(); addr != nil {
+				entry.ServerIPAddress = addr.String()
+			}
+			h.m.Unlock()
+		},
+		WroteHeaderField: func(key string, values []string) {
+			h.m.Lock()
+			for _, value := range values {
+				entry.wire = append(entry.wire, harNameValue{Name: key, Value: value})
+			}
+			h.m.Unlock()
+		},
+		WroteRequest:         func(httptrace.WroteRequestInfo) { now(&t.wroteRequest) },
+		GotFirstResponseByte: func() { now(&t.firstByte) },
+	}
+
+	h.m.Lock()
+	h.entries = append(h.entries, entry)
+	if h.MaxEntries > 0 && len(h.entries) > h.MaxEntries {
+		h.entries = slices.Delete(h.entries, 0, len(h.entries)-h.MaxEntries)
+	}
+	h.m.Unlock()
+	return req.WithContext(httptrace.WithClientTrace(req.Context(), trace)), entry
+}
+
+func (h *HAR) fail(entry *harEntry, err error) {
+	h.m.Lock()
+	defer h.m.Unlock()
+	entry.Error = err.Error()
+	h.wireHeaders(entry)
+	entry.finish(time.Now())
+}
+
+// wireHeaders replaces the request headers with the ones the transport wrote
+func (h *HAR) wireHeaders(entry *harEntry) {
+	if len(entry.wire) == 0 {
+		return
+	}
+	cookie := http.Header{}
+	headers := make([]harNameValue, len(entry.wire))
+	for i, field := range entry.wire {
+		if strings.EqualFold(field.Name, "Cookie") {
+			cookie.Add("Cookie", field.Value)
+		}
+		headers[i] = harNameValue{Name: field.Name, Value: h.redact(field.Name, field.Value)}
+	}
+	entry.Request.Headers = headers
+	entry.Request.Cookies = h.cookies((&http.Request{Header: cookie}).Cookies(), "Cookie")
+	entry.wire = nil
+}
+
+// response the header must be taken before the body is decoded
+func (h *HAR) response(entry *harEntry, resp *http.Response, header http.Header) {
+	h.m.Lock()
+	defer h.m.Unlock()
+	entry.Request.HTTPVersion = resp.Proto
+	h.wireHeaders(entry)
+	entry.Response = harResponse{
+		Status:      resp.StatusCode,
+		StatusText:  http.StatusText(resp.StatusCode),
+		HTTPVersion: resp.Proto,
+		Cookies:     h.cookies(resp.Cookies(), "Set-Cookie"),
+		Headers:     h.headers(header, nil),
+		Content:     harContent{MimeType: header.Get("Content-Type")},
+		RedirectURL: header.Get("Location"),
+		HeadersSize: -1,
+		BodySize:    resp.ContentLength,
+	}
+}
+
+func (h *HAR) body(entry *harEntry, body io.ReadCloser) io.ReadCloser {
+	return &harBody{har: h, entry: entry, body: body}
+}
+
+type harBody struct {
+	har   *HAR
+	entry *harEntry
+	body  io.ReadCloser
+	size  int64
+	data  []byte
+	once  sync.Once
+}
+
+func (b *harBody) Read(p []byte) (int, error) {
+	n, err := b.body.Read(p)
+	b.size += int64(n)
+	if room := b.har.maxBody - len(b.data); room > 0 {
+		b.data = append(b.data, p[:min(n, room)]...)
+	}
+	if err == io.EOF {
+		b.done()
+	}
+	return n, err
+}
+
+func (b *harBody) Close() error {
+	b.done()
+	return b.body.Close()
+}
+
+func (b *harBody) done() {
+	b.once.Do(func() {
+		b.har.m.Lock()
+		defer b.har.m.Unlock()
+		b.entry.Response.Content.Size = b.size
+		b.entry.Response.Content.Text, b.entry.Response.Content.Encoding = harText(b.data)
+		b.entry.finish(time.Now())
+	})
+}
+
+// finish fills timings, HAR 1.2 only allows -1 for blocked, dns, connect and
+// ssl, send, wait and receive of a failed request are 0
+func (e *harEntry) finish(end time.Time) {
+	t := &e.trace
+	ms := func(from, to time.Time) float64 {
+		if from.IsZero() || to.IsZero() {
+			return -1
+		}
+		return float64(to.Sub(from).Microseconds()) / 1000
+	}
+	required := func(from, to time.Time) float64 {
+		return max(ms(from, to), 0)
+	}
+	e.Timings = harTimings{
+		Blocked: ms(t.getConn, firstNonZero(t.dnsStart, t.connectStart, t.gotConn)),
+		DNS:     ms(t.dnsStart, t.dnsDone),
+		Connect: ms(t.connectStart, firstNonZero(t.tlsDone, t.connectDone)),
+		SSL:     ms(t.tlsStart, t.tlsDone),
+		Send:    required(t.gotConn, t.wroteRequest),
+		Wait:    required(t.wroteRequest, t.firstByte),
+		Receive: required(t.firstByte, end),
+	}
+	e.Time = float64(end.Sub(e.StartedDateTime).Microseconds()) / 1000
+}
+
+func firstNonZero(times ...time.Time) time.Time {
+	for _, t := range times {
+		if !t.IsZero() {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// headers names listed in order come first, the rest sorted
+func (h *HAR) headers(header http.Header, order []string) []harNameValue {
+	keys := slices.Clone(order)
+	var rest []string
+	for name := range header {
+		if !slices.Contains(keys, name) {
+			rest = append(rest, name)
+		}
+	}
+	slices.Sort(rest)
+	list := []harNameValue{}
+	for _, name := range append(keys, rest...) {
+		for _, value := range header[name] {
+			list = append(list, harNameValue{Name: name, Value: h.redact(name, value)})
+		}
+	}
+	return list
+}
+
+// cookies values are redacted together with the header they came from
+func (h *HAR) cookies(cookies []*http.Cookie, from string) []harNameValue {
+	list := make([]harNameValue, len(cookies))
+	for i, c := range cookies {
+		list[i] = harNameValue{Name: c.Name, Value: h.redact(from, c.Value)}
+	}
+	return list
+}
+
+func (h *HAR) redact(name, value string) string {
+	if slices.ContainsFunc(h.Redact, func(key string) bool { return strings.EqualFold(key, name) }) {
+		return "REDACTED"
+	}
+	return value
+}
+
+func harText(data []byte) (string, string) {
+	if utf8.Valid(data) {
+		return string(data), ""
+	}
+	return base64.StdEncoding.EncodeToString(data), "base64"
+}
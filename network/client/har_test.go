@@ -0,0 +1,132 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sunls24/gox/types"
+)
+
+func TestHAR(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		_, _ = w.Write([]byte("hello world"))
+	}))
+	defer server.Close()
+
+	har := NewHAR(5)
+	c := New(WithHAR(har))
+	if _, err := c.Post(context.Background(), server.URL+"?q=1", map[string]int{"a": 1}); err != nil {
+		t.Fatalf("Post() error = %v", err)
+	}
+	var buf bytes.Buffer
+	if err := har.Write(&buf); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	var log struct {
+		Log struct {
+			Version string `json:"version"`
+			Entries []struct {
+				Request struct {
+					Method      string `json:"method"`
+					QueryString []struct {
+						Name string `json:"name"`
+					} `json:"queryString"`
+					PostData struct {
+						Text string `json:"text"`
+					} `json:"postData"`
+				} `json:"request"`
+				Response struct {
+					Status  int `json:"status"`
+					Content struct {
+						Size int    `json:"size"`
+						Text string `json:"text"`
+					} `json:"content"`
+				} `json:"response"`
+				Timings struct {
+					Wait float64 `json:"wait"`
+				} `json:"timings"`
+			} `json:"entries"`
+		} `json:"log"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &log); err != nil {
+		t.Fatalf("unmarshal HAR: %v", err)
+	}
+	if log.Log.Version != "1.2" || len(log.Log.Entries) != 1 {
+		t.Fatalf("HAR = %s", buf.String())
+	}
+	entry := log.Log.Entries[0]
+	if entry.Request.Method != http.MethodPost || len(entry.Request.QueryString) != 1 || entry.Request.PostData.Text != `{"a":` {
+		t.Fatalf("request = %+v", entry.Request)
+	}
+	if entry.Response.Status != http.StatusOK || entry.Response.Content.Size != 11 || entry.Response.Content.Text != "hello" {
+		t.Fatalf("response = %+v", entry.Response)
+	}
+	if entry.Timings.Wait < 0 {
+		t.Fatalf("wait = %v, want measured", entry.Timings.Wait)
+	}
+}
+
+func TestHARLimits(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	server.Close()
+
+	har := NewHAR(0)
+	har.MaxEntries = 2
+	c := New(WithHAR(har))
+	for range 3 {
+		if _, err := c.Get(context.Background(), server.URL); err == nil {
+			t.Fatal("Get() error = nil, want connection error")
+		}
+	}
+	har.m.Lock()
+	entries := har.entries
+	har.m.Unlock()
+	if len(entries) != 2 {
+		t.Fatalf("entries = %d, want 2", len(entries))
+	}
+	if timings := entries[0].Timings; timings.Send != 0 || timings.Wait != 0 || timings.Receive != 0 {
+		t.Fatalf("timings = %+v, want 0 for send, wait and receive", timings)
+	}
+	har.Reset()
+	if har.entries != nil {
+		t.Fatal("Reset() kept entries")
+	}
+}
+
+func TestHARHeaders(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "s", Value: "1"})
+	}))
+	defer server.Close()
+
+	har := NewHAR(0)
+	c := New(WithJar(), WithHAR(har), WithHeader(types.NewPair("Authorization", "Bearer t")))
+	for range 2 {
+		if _, err := c.Get(context.Background(), server.URL); err != nil {
+			t.Fatalf("Get() error = %v", err)
+		}
+	}
+	har.m.Lock()
+	defer har.m.Unlock()
+	request := har.entries[1].Request
+	var names []string
+	values := map[string]string{}
+	for _, h := range request.Headers {
+		names = append(names, h.Name)
+		values[h.Name] = h.Value
+	}
+	if names[0] != "Host" || values["Cookie"] != "REDACTED" || values["Authorization"] != "REDACTED" {
+		t.Fatalf("headers = %+v, want wire order with redacted credentials", request.Headers)
+	}
+	if len(request.Cookies) != 1 || request.Cookies[0] != (harNameValue{Name: "s", Value: "REDACTED"}) {
+		t.Fatalf("cookies = %+v", request.Cookies)
+	}
+	if cookies := har.entries[1].Response.Cookies; len(cookies) != 1 || cookies[0].Value != "REDACTED" {
+		t.Fatalf("response cookies = %+v", cookies)
+	}
+}
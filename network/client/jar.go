@@ -0,0 +1,426 @@
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// WithCookieJar jar is usually a *Jar opened from a file
+func WithCookieJar(jar http.CookieJar) Option {
+	return func(c *Client) {
+		c.client.Jar = jar
+	}
+}
+
+// Cookie field names follow the JSON exported by browser cookie extensions
+type Cookie struct {
+	Domain         string  `json:"domain"`
+	Name           string  `json:"name"`
+	Value          string  `json:"value"`
+	Path           string  `json:"path"`
+	ExpirationDate float64 `json:"expirationDate,omitempty"`
+	HostOnly       bool    `json:"hostOnly"`
+	HTTPOnly       bool    `json:"httpOnly"`
+	Secure         bool    `json:"secure"`
+	Session        bool    `json:"session"`
+	SameSite       string  `json:"sameSite,omitempty"`
+}
+
+func (c *Cookie) expires() time.Time {
+	if c.Session || c.ExpirationDate <= 0 {
+		return time.Time{}
+	}
+	sec, frac := math.Modf(c.ExpirationDate)
+	return time.Unix(int64(sec), int64(frac*1e9))
+}
+
+func (c *Cookie) expired(now time.Time) bool {
+	expires := c.expires()
+	return !expires.IsZero() && !expires.After(now)
+}
+
+func (c *Cookie) key() string {
+	return c.Domain + ";" + c.Path + ";" + c.Name
+}
+
+// Jar an http.CookieJar that can be saved to and loaded from a file, session
+// cookies are kept as well so a login survives restarts. It does not consult
+// the public suffix list.
+type Jar struct {
+	path    string
+	m       sync.Mutex
+	saveM   sync.Mutex
+	saveErr error
+	cookies map[string]*Cookie
+}
+
+func NewJar() *Jar {
+	return &Jar{cookies: make(map[string]*Cookie)}
+}
+
+// OpenJar loads path if it exists and saves every change back to it. Files
+// ending in .txt use the Netscape cookies.txt format, others JSON.
+func OpenJar(path string) (*Jar, error) {
+	jar := NewJar()
+	file, err := os.Open(path)
+	if err == nil {
+		err = jar.Import(file)
+		_ = file.Close()
+		if err != nil {
+			return nil, fmt.Errorf("load cookie jar: %w", err)
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	jar.path = path
+	return jar, nil
+}
+
+func (j *Jar) SetCookies(u *url.URL, cookies []*http.Cookie) {
+	host := canonicalHost(u.Host)
+	now := time.Now()
+	j.m.Lock()
+	changed := false
+	for _, hc := range cookies {
+		c, ok := newJarCookie(u, host, hc, now)
+		if !ok {
+			continue
+		}
+		changed = true
+		if c.expired(now) {
+			delete(j.cookies, c.key())
+			continue
+		}
+		j.cookies[c.key()] = c
+	}
+	j.m.Unlock()
+	if changed {
+		err := j.Save()
+		j.m.Lock()
+		j.saveErr = err
+		j.m.Unlock()
+	}
+}
+
+// LastError the error of the save that followed the last cookie change, the
+// http.CookieJar interface has no way to return it
+func (j *Jar) LastError() error {
+	j.m.Lock()
+	defer j.m.Unlock()
+	return j.saveErr
+}
+
+func newJarCookie(u *url.URL, host string, hc *http.Cookie, now time.Time) (*Cookie, bool) {
+	c := &Cookie{
+		Name:     hc.Name,
+		Value:    hc.Value,
+		Path:     hc.Path,
+		HTTPOnly: hc.HttpOnly,
+		Secure:   hc.Secure,
+		Domain:   host,
+		HostOnly: true,
+	}
+	if domain := strings.TrimPrefix(strings.ToLower(hc.Domain), "."); domain != "" && domain != host {
+		if net.ParseIP(host) != nil || !strings.Contains(domain, ".") || !strings.HasSuffix(host, "."+domain) {
+			return nil, false
+		}
+		c.Domain, c.HostOnly = domain, false
+	}
+	if c.Path == "" || c.Path[0] != '/' {
+		c.Path = defaultPath(u.Path)
+	}
+	switch hc.SameSite {
+	case http.SameSiteLaxMode:
+		c.SameSite = "lax"
+	case http.SameSiteStrictMode:
+		c.SameSite = "strict"
+	case http.SameSiteNoneMode:
+		c.SameSite = "no_restriction"
+	}
+	switch {
+	case hc.MaxAge < 0:
+		c.ExpirationDate = 1
+	case hc.MaxAge > 0:
+		c.ExpirationDate = float64(now.Add(time.Duration(hc.MaxAge) * time.Second).Unix())
+	case !hc.Expires.IsZero():
+		c.ExpirationDate = float64(hc.Expires.Unix())
+	default:
+		c.Session = true
+	}
+	return c, true
+}
+
+func (j *Jar) Cookies(u *url.URL) []*http.Cookie {
+	host := canonicalHost(u.Host)
+	path := u.Path
+	if path == "" {
+		path = "/"
+	}
+	secure := u.Scheme == "https" || u.Scheme == "wss"
+	now := time.Now()
+
+	j.m.Lock()
+	var matched []*Cookie
+	for key, c := range j.cookies {
+		if c.expired(now) {
+			delete(j.cookies, key)
+			continue
+		}
+		if c.Secure && !secure || !domainMatch(c, host) || !pathMatch(c.Path, path) {
+			continue
+		}
+		matched = append(matched, c)
+	}
+	j.m.Unlock()
+
+	sort.Slice(matched, func(a, b int) bool {
+		return len(matched[a].Path) > len(matched[b].Path)
+	})
+	cookies := make([]*http.Cookie, len(matched))
+	for i, c := range matched {
+		cookies[i] = &http.Cookie{Name: c.Name, Value: c.Value}
+	}
+	return cookies
+}
+
+// All returns a copy of every stored cookie
+func (j *Jar) All() []Cookie {
+	j.m.Lock()
+	defer j.m.Unlock()
+	list := make([]Cookie, 0, len(j.cookies))
+	for _, c := range j.cookies {
+		list = append(list, *c)
+	}
+	sort.Slice(list, func(a, b int) bool {
+		return list[a].key() < list[b].key()
+	})
+	return list
+}
+
+// Import reads JSON exported by browser extensions or this jar, or a Netscape cookies.txt
+func (j *Jar) Import(r io.Reader) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	var cookies []Cookie
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '[' {
+		err = json.Unmarshal(trimmed, &cookies)
+	} else {
+		cookies, err = parseNetscape(data)
+	}
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	j.m.Lock()
+	defer j.m.Unlock()
+	for _, c := range cookies {
+		c.Domain = strings.ToLower(c.Domain)
+		if strings.HasPrefix(c.Domain, ".") {
+			c.Domain, c.HostOnly = c.Domain[1:], false
+		}
+		if c.Path == "" {
+			c.Path = "/"
+		}
+		if c.ExpirationDate <= 0 {
+			c.Session = true
+		}
+		if c.Name == "" || c.Domain == "" || c.expired(now) {
+			continue
+		}
+		j.cookies[c.key()] = &c
+	}
+	return nil
+}
+
+func (j *Jar) WriteJSON(w io.Writer) error {
+	data, err := json.MarshalIndent(j.All(), "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+func (j *Jar) WriteNetscape(w io.Writer) error {
+	buf := bufio.NewWriter(w)
+	buf.WriteString("# Netscape HTTP Cookie File\n")
+	for _, c := range j.All() {
+		domain := c.Domain
+		if !c.HostOnly {
+			domain = "." + domain
+		}
+		if c.HTTPOnly {
+			domain = "#HttpOnly_" + domain
+		}
+		expires := int64(0)
+		if !c.Session {
+			expires = int64(c.ExpirationDate)
+		}
+		fmt.Fprintf(buf, "%s\t%s\t%s\t%s\t%d\t%s\t%s\n",
+			domain, netscapeBool(!c.HostOnly), c.Path, netscapeBool(c.Secure), expires, c.Name, c.Value)
+	}
+	return buf.Flush()
+}
+
+// Save writes the jar to the file it was opened from, no-op for NewJar
+func (j *Jar) Save() error {
+	if j.path == "" {
+		return nil
+	}
+	j.saveM.Lock()
+	defer j.saveM.Unlock()
+	var buf bytes.Buffer
+	var err error
+	if strings.EqualFold(filepath.Ext(j.path), ".txt") {
+		err = j.WriteNetscape(&buf)
+	} else {
+		err = j.WriteJSON(&buf)
+	}
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(j.path), 0o700); err != nil {
+		return err
+	}
+	tmp := j.path + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, j.path)
+}
+
+func parseNetscape(data []byte) ([]Cookie, error) {
+	var cookies []Cookie
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimRight(scanner.Text(), "\r")
+		httpOnly := false
+		if after, ok := strings.CutPrefix(text, "#HttpOnly_"); ok {
+			text, httpOnly = after, true
+		}
+		if strings.TrimSpace(text) == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		fields := strings.Split(text, "\t")
+		if len(fields) < 7 {
+			return nil, fmt.Errorf("cookies.txt line %d: want 7 fields, got %d", line, len(fields))
+		}
+		expires, err := strconv.ParseInt(fields[4], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("cookies.txt line %d: %w", line, err)
+		}
+		cookies = append(cookies, Cookie{
+			Domain:         fields[0],
+			HostOnly:       !strings.EqualFold(fields[1], "TRUE") && !strings.HasPrefix(fields[0], "."),
+			Path:           fields[2],
+			Secure:         strings.EqualFold(fields[3], "TRUE"),
+			ExpirationDate: float64(expires),
+			Name:           fields[5],
+			Value:          strings.Join(fields[6:], "\t"),
+			HTTPOnly:       httpOnly,
+		})
+	}
+	return cookies, scanner.Err()
+}
+
+func netscapeBool(b bool) string {
+	if b {
+		return "TRUE"
+	}
+	return "FALSE"
+}
+
+func canonicalHost(host string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return strings.ToLower(strings.TrimSuffix(host, "."))
+}
+
+func defaultPath(path string) string {
+	i := strings.LastIndex(path, "/")
+	if i <= 0 {
+		return "/"
+	}
+	return path[:i]
+}
+
+func domainMatch(c *Cookie, host string) bool {
+	if host == c.Domain {
+		return true
+	}
+	return !c.HostOnly && strings.HasSuffix(host, "."+c.Domain)
+}
+
+func pathMatch(cookiePath, path string) bool {
+	if !strings.HasPrefix(path, cookiePath) {
+		return false
+	}
+	return len(path) == len(cookiePath) || strings.HasSuffix(cookiePath, "/") || path[len(cookiePath)] == '/'
+}
+
+// JarStore keeps one persistent jar per account under dir
+type JarStore struct {
+	dir  string
+	ext  string
+	m    sync.Mutex
+	jars map[string]*Jar
+}
+
+// NewJarStore ext is the file extension, ".json" or ".txt"
+func NewJarStore(dir, ext string) *JarStore {
+	if ext == "" {
+		ext = ".json"
+	}
+	return &JarStore{dir: dir, ext: ext, jars: make(map[string]*Jar)}
+}
+
+func (s *JarStore) Jar(account string) (*Jar, error) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	if jar, ok := s.jars[account]; ok {
+		return jar, nil
+	}
+	name := accountFileName(account)
+	if strings.Trim(name, ".") == "" {
+		return nil, fmt.Errorf("invalid cookie jar account: %q", account)
+	}
+	jar, err := OpenJar(filepath.Join(s.dir, name+s.ext))
+	if err != nil {
+		return nil, err
+	}
+	s.jars[account] = jar
+	return jar, nil
+}
+
+// accountFileName percent-encodes every byte outside [A-Za-z0-9._@-], so
+// different accounts never share a file
+func accountFileName(account string) string {
+	var b strings.Builder
+	for i := 0; i < len(account); i++ {
+		c := account[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' || strings.IndexByte("._@-", c) >= 0 {
+			b.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&b, "%%%02X", c)
+	}
+	return b.String()
+}
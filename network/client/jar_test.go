@@ -0,0 +1,90 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestJarPersists(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/login" {
+			http.SetCookie(w, &http.Cookie{Name: "session", Value: "abc", Path: "/", HttpOnly: true})
+			return
+		}
+		cookie, err := r.Cookie("session")
+		if err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte(cookie.Value))
+	}))
+	defer server.Close()
+
+	store := NewJarStore(t.TempDir(), ".txt")
+	jar, err := store.Jar("user@example.com")
+	if err != nil {
+		t.Fatalf("Jar() error = %v", err)
+	}
+	if _, err := New(WithCookieJar(jar)).Get(context.Background(), server.URL+"/login"); err != nil {
+		t.Fatalf("login error = %v", err)
+	}
+
+	reopened, err := OpenJar(filepath.Join(store.dir, "user@example.com.txt"))
+	if err != nil {
+		t.Fatalf("OpenJar() error = %v", err)
+	}
+	body, err := New(WithCookieJar(reopened)).Get(context.Background(), server.URL+"/me")
+	if err != nil || string(body) != "abc" {
+		t.Fatalf("Get() = %q, %v, want abc", body, err)
+	}
+}
+
+func TestJarImportBrowserJSON(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cookies.json")
+	exported := `[{"domain":".example.com","name":"sid","value":"1","path":"/","expirationDate":4102444800.5,"hostOnly":false,"httpOnly":true,"secure":true,"session":false}]`
+	if err := os.WriteFile(path, []byte(exported), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	jar, err := OpenJar(path)
+	if err != nil {
+		t.Fatalf("OpenJar() error = %v", err)
+	}
+	for _, raw := range []string{"https://www.example.com/a", "http://www.example.com/a", "https://example.org/"} {
+		u, _ := http.NewRequest(http.MethodGet, raw, nil)
+		cookies := jar.Cookies(u.URL)
+		want := strings.HasPrefix(raw, "https://www.example.com")
+		if (len(cookies) == 1) != want {
+			t.Errorf("Cookies(%s) = %v, want match %v", raw, cookies, want)
+		}
+	}
+}
+
+func TestJarStoreAccountsAndSaveErrors(t *testing.T) {
+	store := NewJarStore(t.TempDir(), "")
+	a, _ := store.Jar("a/b")
+	b, _ := store.Jar("a_b")
+	if a.path == b.path {
+		t.Fatalf("accounts a/b and a_b share %s", a.path)
+	}
+	if _, err := store.Jar(".."); err == nil {
+		t.Fatal("Jar(..) error = nil, want invalid account")
+	}
+
+	file := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(file, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	jar := NewJar()
+	jar.path = filepath.Join(file, "jar.json")
+	u, _ := url.Parse("https://example.com/")
+	jar.SetCookies(u, []*http.Cookie{{Name: "a", Value: "1"}})
+	if jar.LastError() == nil {
+		t.Fatal("LastError() = nil, want save error")
+	}
+}
@@ -0,0 +1,109 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/sunls24/gox/types"
+)
+
+const snippetSize = 512
+
+type DecodeError struct {
+	StatusCode  int
+	ContentType string
+	Snippet     string
+	Err         error
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("decode json response (%d %s): %v: %s", e.StatusCode, e.ContentType, e.Err, e.Snippet)
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// DoJSON c is nil use default client
+func DoJSON[T any](c *Client, req *http.Request, header ...types.Pair[string]) (T, error) {
+	var v T
+	if c == nil {
+		c = def
+	}
+	if req.Header.Get("Accept") == "" {
+		req.Header.Set("Accept", "application/json")
+	}
+	resp, err := c.do(req, header...)
+	if err != nil {
+		return v, err
+	}
+	defer Close(resp.Body)
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return v, err
+	}
+	// 204 and other empty 2xx bodies carry no value
+	if len(bytes.TrimSpace(body)) == 0 {
+		return v, nil
+	}
+	contentType := resp.Header.Get("Content-Type")
+	if !isJSON(contentType) {
+		return v, newDecodeError(resp, body, fmt.Errorf("unexpected content type %q", contentType))
+	}
+	if err := json.Unmarshal(body, &v); err != nil {
+		return v, newDecodeError(resp, body, err)
+	}
+	return v, nil
+}
+
+func GetJSON[T any](ctx context.Context, c *Client, url string, header ...types.Pair[string]) (T, error) {
+	req, err := NewRequest(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		var v T
+		return v, err
+	}
+	return DoJSON[T](c, req, header...)
+}
+
+func PostJSON[T any](ctx context.Context, c *Client, url string, body any, header ...types.Pair[string]) (T, error) {
+	req, err := NewRequest(ctx, http.MethodPost, url, body)
+	if err != nil {
+		var v T
+		return v, err
+	}
+	return DoJSON[T](c, req, header...)
+}
+
+// isJSON empty content type is accepted, many APIs omit it
+func isJSON(contentType string) bool {
+	if contentType == "" {
+		return true
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+func newDecodeError(resp *http.Response, body []byte, err error) *DecodeError {
+	return &DecodeError{
+		StatusCode:  resp.StatusCode,
+		ContentType: resp.Header.Get("Content-Type"),
+		Snippet:     snippet(body, snippetSize),
+		Err:         err,
+	}
+}
+
+func snippet(body []byte, size int) string {
+	if len(body) <= size {
+		return string(body)
+	}
+	return strings.ToValidUTF8(string(body[:size]), "") + "..."
+}
@@ -0,0 +1,52 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestGetJSON(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/empty" {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		if r.URL.Path == "/html" {
+			w.Header().Set("Content-Type", "text/html")
+			_, _ = w.Write([]byte("<html>" + strings.Repeat("x", 1000)))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		_, _ = w.Write([]byte(`{"name":"gox"}`))
+	}))
+	defer server.Close()
+
+	type result struct {
+		Name string `json:"name"`
+	}
+	v, err := GetJSON[result](context.Background(), nil, server.URL)
+	if err != nil {
+		t.Fatalf("GetJSON() error = %v", err)
+	}
+	if v.Name != "gox" {
+		t.Fatalf("name = %q, want gox", v.Name)
+	}
+
+	v, err = GetJSON[result](context.Background(), nil, server.URL+"/empty")
+	if err != nil || v.Name != "" {
+		t.Fatalf("GetJSON() = %+v, %v, want zero value for 204", v, err)
+	}
+
+	_, err = GetJSON[result](context.Background(), New(), server.URL+"/html")
+	var decodeErr *DecodeError
+	if !errors.As(err, &decodeErr) {
+		t.Fatalf("GetJSON() error = %v, want *DecodeError", err)
+	}
+	if decodeErr.StatusCode != http.StatusOK || len(decodeErr.Snippet) != snippetSize+3 {
+		t.Fatalf("decode error = %+v, want status 200 and truncated snippet", decodeErr)
+	}
+}
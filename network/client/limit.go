@@ -0,0 +1,84 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+var ErrResponseTooLarge = errors.New("response body too large")
+
+// WithMaxResponseSize limits every response body, reads past the limit fail
+// with ErrResponseTooLarge. Bodies of non-2xx responses are truncated to the
+// limit instead. Download and Events are not limited unless the context sets a
+// size with ContextWithMaxResponseSize.
+func WithMaxResponseSize(size int64) Option {
+	return func(c *Client) {
+		c.maxResponse = size
+	}
+}
+
+type maxResponseKey struct {
+}
+
+// ContextWithMaxResponseSize overrides WithMaxResponseSize for one call, 0 removes the limit
+func ContextWithMaxResponseSize(ctx context.Context, size int64) context.Context {
+	return context.WithValue(ctx, maxResponseKey{}, size)
+}
+
+// withoutResponseLimit lifts the client limit for streams and downloads, a
+// size set on ctx by the caller is kept
+func withoutResponseLimit(ctx context.Context) context.Context {
+	if _, ok := ctx.Value(maxResponseKey{}).(int64); ok {
+		return ctx
+	}
+	return ContextWithMaxResponseSize(ctx, 0)
+}
+
+func (c *Client) responseLimit(ctx context.Context) int64 {
+	if size, ok := ctx.Value(maxResponseKey{}).(int64); ok {
+		return size
+	}
+	return c.maxResponse
+}
+
+func limitResponse(resp *http.Response, limit int64) error {
+	if statusOK(resp.StatusCode) && resp.ContentLength > limit {
+		_ = resp.Body.Close()
+		return responseTooLarge(limit)
+	}
+	resp.Body = &limitedBody{body: resp.Body, limit: limit, remaining: limit}
+	return nil
+}
+
+func responseTooLarge(limit int64) error {
+	return fmt.Errorf("%w: limit %d bytes", ErrResponseTooLarge, limit)
+}
+
+type limitedBody struct {
+	body      io.ReadCloser
+	limit     int64
+	remaining int64
+}
+
+// Read reads one byte past the limit to tell an exact fit from an overflow
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.remaining < 0 {
+		return 0, responseTooLarge(b.limit)
+	}
+	if int64(len(p)) > b.remaining+1 {
+		p = p[:b.remaining+1]
+	}
+	n, err := b.body.Read(p)
+	b.remaining -= int64(n)
+	if b.remaining < 0 {
+		return n - 1, responseTooLarge(b.limit)
+	}
+	return n, err
+}
+
+func (b *limitedBody) Close() error {
+	return b.body.Close()
+}
@@ -0,0 +1,50 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMaxResponseSize(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		if r.URL.Query().Has("chunked") {
+			w.(http.Flusher).Flush()
+		}
+		_, _ = w.Write([]byte(strings.Repeat("x", 100)))
+	}))
+	defer server.Close()
+
+	c := New(WithMaxResponseSize(10))
+	ctx := context.Background()
+	for _, path := range []string{"/", "/?chunked"} {
+		if _, err := c.Get(ctx, server.URL+path); !errors.Is(err, ErrResponseTooLarge) {
+			t.Fatalf("Get(%s) error = %v, want ErrResponseTooLarge", path, err)
+		}
+	}
+	body, err := c.Get(ContextWithMaxResponseSize(ctx, 100), server.URL)
+	if err != nil || len(body) != 100 {
+		t.Fatalf("Get() with call limit = %d bytes, %v", len(body), err)
+	}
+	_, err = c.Get(ctx, server.URL+"/fail")
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || len(statusErr.Body) != 10 {
+		t.Fatalf("Get(/fail) error = %v, want StatusError with 10 byte body", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "model.bin")
+	if err := c.Download(ctx, server.URL, path, DownloadConfig{}); err != nil {
+		t.Fatalf("Download() error = %v, want no client limit", err)
+	}
+	if info, err := os.Stat(path); err != nil || info.Size() != 100 {
+		t.Fatalf("downloaded file = %v, %v, want 100 bytes", info, err)
+	}
+}
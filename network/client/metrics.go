@@ -0,0 +1,157 @@
+package client
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60}
+
+// Metrics counts requests by host, method and status and observes latency by
+// host and method, it serves the Prometheus text format
+type Metrics struct {
+	buckets   []float64
+	m         sync.Mutex
+	requests  map[requestLabels]uint64
+	durations map[durationLabels]*histogram
+}
+
+type requestLabels struct {
+	host, method, status string
+}
+
+type durationLabels struct {
+	host, method string
+}
+
+type histogram struct {
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+// NewMetrics buckets in seconds, nil uses DefaultBuckets
+func NewMetrics(buckets []float64) *Metrics {
+	if buckets == nil {
+		buckets = DefaultBuckets
+	}
+	buckets = slices.Clone(buckets)
+	slices.Sort(buckets)
+	return &Metrics{
+		buckets:   buckets,
+		requests:  make(map[requestLabels]uint64),
+		durations: make(map[durationLabels]*histogram),
+	}
+}
+
+// WithMetrics records every attempt, network errors use status "error"
+func WithMetrics(metrics *Metrics) Option {
+	return WithMiddleware(metrics.Middleware())
+}
+
+// WithInstrumentation propagates trace context with TraceMiddleware and records
+// metrics, metrics can be nil for tracing only
+func WithInstrumentation(metrics *Metrics) Option {
+	if metrics == nil {
+		return WithMiddleware(TraceMiddleware())
+	}
+	return WithMiddleware(TraceMiddleware(), metrics.Middleware())
+}
+
+func (m *Metrics) Middleware() Middleware {
+	return func(next RoundTrip) RoundTrip {
+		return func(req *http.Request) (*http.Response, error) {
+			start := time.Now()
+			resp, err := next(req)
+			status := "error"
+			if err == nil {
+				status = strconv.Itoa(resp.StatusCode)
+			}
+			m.observe(req.URL.Host, req.Method, status, time.Since(start))
+			return resp, err
+		}
+	}
+}
+
+func (m *Metrics) observe(host, method, status string, d time.Duration) {
+	m.m.Lock()
+	defer m.m.Unlock()
+	m.requests[requestLabels{host, method, status}]++
+	key := durationLabels{host, method}
+	h, ok := m.durations[key]
+	if !ok {
+		h = &histogram{counts: make([]uint64, len(m.buckets))}
+		m.durations[key] = h
+	}
+	seconds := d.Seconds()
+	if i, _ := slices.BinarySearch(m.buckets, seconds); i < len(m.buckets) {
+		h.counts[i]++
+	}
+	h.sum += seconds
+	h.count++
+}
+
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_ = m.Write(w)
+}
+
+func (m *Metrics) Write(w io.Writer) error {
+	m.m.Lock()
+	defer m.m.Unlock()
+	buf := bufio.NewWriter(w)
+
+	buf.WriteString("# HELP http_client_requests_total Outbound HTTP requests.\n")
+	buf.WriteString("# TYPE http_client_requests_total counter\n")
+	requests := make([]requestLabels, 0, len(m.requests))
+	for k := range m.requests {
+		requests = append(requests, k)
+	}
+	sort.Slice(requests, func(i, j int) bool {
+		a, b := requests[i], requests[j]
+		return a.host+a.method+a.status < b.host+b.method+b.status
+	})
+	for _, k := range requests {
+		fmt.Fprintf(buf, "http_client_requests_total{host=%s,method=%s,status=%s} %d\n",
+			labelValue(k.host), labelValue(k.method), labelValue(k.status), m.requests[k])
+	}
+
+	buf.WriteString("# HELP http_client_request_duration_seconds Outbound HTTP request latency.\n")
+	buf.WriteString("# TYPE http_client_request_duration_seconds histogram\n")
+	durations := make([]durationLabels, 0, len(m.durations))
+	for k := range m.durations {
+		durations = append(durations, k)
+	}
+	sort.Slice(durations, func(i, j int) bool {
+		a, b := durations[i], durations[j]
+		return a.host+a.method < b.host+b.method
+	})
+	for _, k := range durations {
+		h := m.durations[k]
+		labels := fmt.Sprintf("host=%s,method=%s", labelValue(k.host), labelValue(k.method))
+		var cumulative uint64
+		for i, bound := range m.buckets {
+			cumulative += h.counts[i]
+			fmt.Fprintf(buf, "http_client_request_duration_seconds_bucket{%s,le=\"%s\"} %d\n",
+				labels, strconv.FormatFloat(bound, 'g', -1, 64), cumulative)
+		}
+		fmt.Fprintf(buf, "http_client_request_duration_seconds_bucket{%s,le=\"+Inf\"} %d\n", labels, h.count)
+		fmt.Fprintf(buf, "http_client_request_duration_seconds_sum{%s} %s\n", labels, strconv.FormatFloat(h.sum, 'g', -1, 64))
+		fmt.Fprintf(buf, "http_client_request_duration_seconds_count{%s} %d\n", labels, h.count)
+	}
+	return buf.Flush()
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func labelValue(s string) string {
+	return `"` + labelEscaper.Replace(s) + `"`
+}
@@ -0,0 +1,76 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestInstrumentation(t *testing.T) {
+	var traceparent string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get("traceparent")
+	}))
+	defer server.Close()
+
+	parent, err := ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", "vendor=1")
+	if err != nil {
+		t.Fatalf("ParseTraceparent() error = %v", err)
+	}
+	metrics := NewMetrics([]float64{1, 5})
+	c := New(WithInstrumentation(metrics))
+	if _, err := c.Get(ContextWithTrace(context.Background(), parent), server.URL); err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	child, err := ParseTraceparent(traceparent, "")
+	if err != nil {
+		t.Fatalf("sent traceparent %q: %v", traceparent, err)
+	}
+	if child.TraceID != parent.TraceID || child.SpanID == parent.SpanID || child.Flags != 1 {
+		t.Fatalf("traceparent = %q, want child of %q", traceparent, parent.Traceparent())
+	}
+
+	var buf bytes.Buffer
+	if err := metrics.Write(&buf); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	host := strings.TrimPrefix(server.URL, "http://")
+	for _, want := range []string{
+		`http_client_requests_total{host="` + host + `",method="GET",status="200"} 1`,
+		`http_client_request_duration_seconds_bucket{host="` + host + `",method="GET",le="1"} 1`,
+		`http_client_request_duration_seconds_count{host="` + host + `",method="GET"} 1`,
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("metrics missing %q:\n%s", want, buf.String())
+		}
+	}
+}
+
+func TestTraceNewSpanPerAttempt(t *testing.T) {
+	var traceparents []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparents = append(traceparents, r.Header.Get("traceparent"))
+		if len(traceparents) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	parent, _ := ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", "")
+	c := New(WithRetry(Retry{MaxAttempts: 2, MinBackoff: time.Millisecond}), WithInstrumentation(nil))
+	if _, err := c.Get(ContextWithTrace(context.Background(), parent), server.URL); err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if len(traceparents) != 2 || traceparents[0] == traceparents[1] {
+		t.Fatalf("traceparents = %q, want a new span per attempt", traceparents)
+	}
+	first, _ := ParseTraceparent(traceparents[0], "")
+	second, _ := ParseTraceparent(traceparents[1], "")
+	if first.TraceID != parent.TraceID || second.TraceID != parent.TraceID {
+		t.Fatalf("traceparents = %q, want the same trace", traceparents)
+	}
+}
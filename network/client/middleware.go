@@ -0,0 +1,173 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/sunls24/gox/network/header"
+)
+
+type RoundTrip func(req *http.Request) (*http.Response, error)
+
+type Middleware func(next RoundTrip) RoundTrip
+
+// WithMiddleware the first middleware is the outermost, it runs once per attempt
+func WithMiddleware(middlewares ...Middleware) Option {
+	return func(c *Client) {
+		c.middlewares = append(c.middlewares, middlewares...)
+	}
+}
+
+func (c *Client) roundTrip(req *http.Request) (*http.Response, error) {
+	next := RoundTrip(c.transport)
+	for i := len(c.middlewares) - 1; i >= 0; i-- {
+		next = c.middlewares[i](next)
+	}
+	return next(req)
+}
+
+// transport waits on the rate limiter before the breaker admits the request,
+// so local waiting errors are not counted as upstream failures
+func (c *Client) transport(req *http.Request) (*http.Response, error) {
+	if c.proxyErr != nil {
+		closeBody(req)
+		return nil, c.proxyErr
+	}
+	if c.limiter != nil {
+		if err := c.limiter.wait(req); err != nil {
+			closeBody(req)
+			return nil, err
+		}
+	}
+	if c.breakers != nil {
+		return c.breakers.roundTrip(req, c.proxiedDo)
+	}
+	return c.proxiedDo(req)
+}
+
+func (c *Client) proxiedDo(req *http.Request) (*http.Response, error) {
+	if c.proxies != nil {
+		return c.proxies.roundTrip(req, c.wireDo)
+	}
+	return c.wireDo(req)
+}
+
+func (c *Client) wireDo(req *http.Request) (*http.Response, error) {
+	if !c.rawBody && req.Header.Get("Accept-Encoding") == "" && req.Header.Get("Range") == "" {
+		req.Header.Set("Accept-Encoding", acceptEncoding)
+	}
+	var entry *harEntry
+	if c.har != nil {
+		req, entry = c.har.start(req, c.client.Jar)
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		if entry != nil {
+			c.har.fail(entry, err)
+		}
+		return nil, err
+	}
+	header := resp.Header.Clone()
+	if !c.rawBody {
+		decompressResponse(resp)
+	}
+	if entry != nil {
+		c.har.response(entry, resp, header)
+		resp.Body = c.har.body(entry, resp.Body)
+	}
+	return resp, nil
+}
+
+func LogMiddleware(logger *slog.Logger) Middleware {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return func(next RoundTrip) RoundTrip {
+		return func(req *http.Request) (*http.Response, error) {
+			start := time.Now()
+			resp, err := next(req)
+			attrs := []slog.Attr{
+				slog.String("method", req.Method),
+				slog.String("url", req.URL.Redacted()),
+				slog.Duration("duration", time.Since(start)),
+			}
+			if err != nil {
+				attrs = append(attrs, slog.Any("err", err))
+				logger.LogAttrs(req.Context(), slog.LevelError, "http request failed", attrs...)
+				return nil, err
+			}
+			attrs = append(attrs, slog.Int("status", resp.StatusCode))
+			level := slog.LevelInfo
+			if !statusOK(resp.StatusCode) {
+				level = slog.LevelWarn
+			}
+			logger.LogAttrs(req.Context(), level, "http request", attrs...)
+			return resp, nil
+		}
+	}
+}
+
+// HeaderMiddleware headers already present on the request are kept
+func HeaderMiddleware(b *header.Builder) Middleware {
+	headers := b.Get()
+	return func(next RoundTrip) RoundTrip {
+		return func(req *http.Request) (*http.Response, error) {
+			for _, p := range headers {
+				if _, ok := req.Header[http.CanonicalHeaderKey(p.Key)]; !ok {
+					req.Header.Set(p.Key, p.Value)
+				}
+			}
+			return next(req)
+		}
+	}
+}
+
+// WithSigner signs every attempt, middlewares added after it run after signing,
+// so add it last when they change signed headers
+func WithSigner(signer header.Signer) Option {
+	return WithMiddleware(SignMiddleware(signer))
+}
+
+func SignMiddleware(signer header.Signer) Middleware {
+	return func(next RoundTrip) RoundTrip {
+		return func(req *http.Request) (*http.Response, error) {
+			if err := signer.Sign(req); err != nil {
+				closeBody(req)
+				return nil, fmt.Errorf("sign request: %w", err)
+			}
+			return next(req)
+		}
+	}
+}
+
+const RequestIDHeader = "X-Request-ID"
+
+type requestIDKey struct {
+}
+
+func ContextWithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// RequestIDMiddleware name defaults to RequestIDHeader
+func RequestIDMiddleware(name string) Middleware {
+	if name == "" {
+		name = RequestIDHeader
+	}
+	return func(next RoundTrip) RoundTrip {
+		return func(req *http.Request) (*http.Response, error) {
+			if id := RequestID(req.Context()); id != "" && req.Header.Get(name) == "" {
+				req.Header.Set(name, id)
+			}
+			return next(req)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/sunls24/gox/network/header"
+	"github.com/sunls24/gox/types"
+)
+
+func TestMiddlewareChain(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.Header.Get(RequestIDHeader) + "," + r.Header.Get("Referer") + "," + r.Header.Get("X-Order")))
+	}))
+	defer server.Close()
+
+	var order []string
+	trace := func(name string) Middleware {
+		return func(next RoundTrip) RoundTrip {
+			return func(req *http.Request) (*http.Response, error) {
+				order = append(order, name)
+				req.Header.Set("X-Order", req.Header.Get("X-Order")+name)
+				return next(req)
+			}
+		}
+	}
+	c := New(WithMiddleware(
+		trace("a"),
+		RequestIDMiddleware(""),
+		HeaderMiddleware(header.New().Referer("https://default.example.com")),
+		trace("b"),
+	))
+	ctx := ContextWithRequestID(context.Background(), "req-1")
+	body, err := c.Get(ctx, server.URL, types.NewPair("Referer", "https://call.example.com"))
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if want := "req-1,https://call.example.com,ab"; string(body) != want {
+		t.Fatalf("body = %q, want %q", body, want)
+	}
+	if len(order) != 2 || order[0] != "a" {
+		t.Fatalf("order = %v, want [a b]", order)
+	}
+}
+
+func TestSignerRunsPerAttempt(t *testing.T) {
+	var calls int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if r.Header.Get("X-Signature") != "signed" || len(r.Header.Values("X-Signature")) != 1 {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if calls == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	var signs int
+	signer := header.SignerFunc(func(req *http.Request) error {
+		signs++
+		req.Header.Set("X-Signature", "signed")
+		return nil
+	})
+	c := New(WithRetry(Retry{MaxAttempts: 2, MinBackoff: time.Millisecond}), WithSigner(signer))
+	body, err := c.Get(context.Background(), server.URL)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if string(body) != "ok" || signs != 2 {
+		t.Fatalf("body = %q, signs = %d, want ok signed twice", body, signs)
+	}
+}
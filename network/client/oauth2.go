@@ -0,0 +1,273 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultTokenLeeway how long before expiry a cached token is refreshed
+const DefaultTokenLeeway = time.Minute
+
+type Token struct {
+	AccessToken  string
+	TokenType    string
+	RefreshToken string
+	// Expiry is zero when the server did not send expires_in
+	Expiry time.Time
+}
+
+// Authorization the Authorization header value, token types other than bearer
+// are sent as received
+func (t *Token) Authorization() string {
+	if t.TokenType == "" || strings.EqualFold(t.TokenType, "bearer") {
+		return "Bearer " + t.AccessToken
+	}
+	return t.TokenType + " " + t.AccessToken
+}
+
+func (t *Token) expired(leeway time.Duration) bool {
+	return !t.Expiry.IsZero() && time.Now().Add(leeway).After(t.Expiry)
+}
+
+type TokenSource interface {
+	Token(ctx context.Context) (*Token, error)
+}
+
+// ClientCredentials RFC 6749 client credentials grant, the client is
+// authenticated with HTTP Basic unless CredentialsInBody is set
+type ClientCredentials struct {
+	TokenURL          string
+	ClientID          string
+	ClientSecret      string
+	Scopes            []string
+	Params            url.Values
+	CredentialsInBody bool
+	// Client defaults to the package client
+	Client *Client
+}
+
+func (s *ClientCredentials) Token(ctx context.Context) (*Token, error) {
+	form := url.Values{"grant_type": {"client_credentials"}}
+	if len(s.Scopes) > 0 {
+		form.Set("scope", strings.Join(s.Scopes, " "))
+	}
+	for key, values := range s.Params {
+		form[key] = values
+	}
+	return fetchToken(ctx, s.Client, s.TokenURL, s.ClientID, s.ClientSecret, s.CredentialsInBody, form)
+}
+
+// RefreshToken RFC 6749 refresh token grant, a rotated refresh token returned
+// by the server replaces the current one
+type RefreshToken struct {
+	TokenURL          string
+	ClientID          string
+	ClientSecret      string
+	CredentialsInBody bool
+	// Client defaults to the package client
+	Client *Client
+
+	mu           sync.Mutex
+	refreshToken string
+}
+
+func NewRefreshToken(tokenURL, clientID, clientSecret, refreshToken string) *RefreshToken {
+	return &RefreshToken{TokenURL: tokenURL, ClientID: clientID, ClientSecret: clientSecret, refreshToken: refreshToken}
+}
+
+func (s *RefreshToken) Token(ctx context.Context) (*Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.refreshToken == "" {
+		return nil, errors.New("oauth2: refresh token is empty")
+	}
+	form := url.Values{"grant_type": {"refresh_token"}, "refresh_token": {s.refreshToken}}
+	token, err := fetchToken(ctx, s.Client, s.TokenURL, s.ClientID, s.ClientSecret, s.CredentialsInBody, form)
+	if err != nil {
+		return nil, err
+	}
+	if token.RefreshToken != "" {
+		s.refreshToken = token.RefreshToken
+	}
+	return token, nil
+}
+
+// CurrentRefreshToken the refresh token to persist after rotation
+func (s *RefreshToken) CurrentRefreshToken() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.refreshToken
+}
+
+type tokenResponse struct {
+	AccessToken  string    `json:"access_token"`
+	TokenType    string    `json:"token_type"`
+	RefreshToken string    `json:"refresh_token"`
+	ExpiresIn    expiresIn `json:"expires_in"`
+}
+
+// expiresIn accepts numbers and numeric strings, some servers quote it
+type expiresIn int64
+
+func (e *expiresIn) UnmarshalJSON(data []byte) error {
+	text := string(bytes.Trim(data, `"`))
+	if text == "" || text == "null" {
+		*e = 0
+		return nil
+	}
+	value, err := strconv.ParseInt(text, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid expires_in %s", data)
+	}
+	*e = expiresIn(value)
+	return nil
+}
+
+func fetchToken(ctx context.Context, c *Client, tokenURL, clientID, clientSecret string, inBody bool, form url.Values) (*Token, error) {
+	if inBody {
+		form.Set("client_id", clientID)
+		if clientSecret != "" {
+			form.Set("client_secret", clientSecret)
+		}
+	}
+	req, err := NewRequest(ctx, http.MethodPost, tokenURL, form)
+	if err != nil {
+		return nil, err
+	}
+	if !inBody {
+		req.SetBasicAuth(url.QueryEscape(clientID), url.QueryEscape(clientSecret))
+	}
+	start := time.Now()
+	resp, err := DoJSON[tokenResponse](c, req)
+	if err != nil {
+		return nil, fmt.Errorf("oauth2 token: %w", err)
+	}
+	if resp.AccessToken == "" {
+		return nil, errors.New("oauth2 token: response has no access_token")
+	}
+	token := &Token{AccessToken: resp.AccessToken, TokenType: resp.TokenType, RefreshToken: resp.RefreshToken}
+	if resp.ExpiresIn > 0 {
+		token.Expiry = start.Add(time.Duration(resp.ExpiresIn) * time.Second)
+	}
+	return token, nil
+}
+
+// TokenCache reuses a token until it is within leeway of expiry, concurrent
+// callers share a single fetch
+type TokenCache struct {
+	source TokenSource
+	leeway time.Duration
+
+	mu    sync.Mutex
+	token *Token
+	call  *tokenCall
+}
+
+type tokenCall struct {
+	done  chan struct{}
+	token *Token
+	err   error
+}
+
+// NewTokenCache leeway defaults to DefaultTokenLeeway
+func NewTokenCache(source TokenSource, leeway time.Duration) *TokenCache {
+	if leeway <= 0 {
+		leeway = DefaultTokenLeeway
+	}
+	return &TokenCache{source: source, leeway: leeway}
+}
+
+func (c *TokenCache) Token(ctx context.Context) (*Token, error) {
+	c.mu.Lock()
+	if c.token != nil && !c.token.expired(c.leeway) {
+		token := c.token
+		c.mu.Unlock()
+		return token, nil
+	}
+	call := c.call
+	if call == nil {
+		call = &tokenCall{done: make(chan struct{})}
+		c.call = call
+		// the fetch outlives a caller that gives up, others may still wait on it
+		go c.fetch(context.WithoutCancel(ctx), call)
+	}
+	c.mu.Unlock()
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-call.done:
+		return call.token, call.err
+	}
+}
+
+func (c *TokenCache) fetch(ctx context.Context, call *tokenCall) {
+	call.token, call.err = c.source.Token(ctx)
+	c.mu.Lock()
+	if call.err == nil {
+		c.token = call.token
+	}
+	c.call = nil
+	c.mu.Unlock()
+	close(call.done)
+}
+
+// Invalidate drops token if it is still the cached one, a token refreshed in
+// the meantime is kept
+func (c *TokenCache) Invalidate(token *Token) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.token == token {
+		c.token = nil
+	}
+}
+
+// WithTokenSource sets the Authorization header from source on every request,
+// a 401 response invalidates the token and the request is sent once more with
+// a new one. Sources other than *TokenCache are cached with DefaultTokenLeeway.
+func WithTokenSource(source TokenSource) Option {
+	return WithMiddleware(TokenMiddleware(source))
+}
+
+func TokenMiddleware(source TokenSource) Middleware {
+	cache, ok := source.(*TokenCache)
+	if !ok {
+		cache = NewTokenCache(source, 0)
+	}
+	return func(next RoundTrip) RoundTrip {
+		return func(req *http.Request) (*http.Response, error) {
+			token, err := cache.Token(req.Context())
+			if err != nil {
+				closeBody(req)
+				return nil, err
+			}
+			req.Header.Set("Authorization", token.Authorization())
+			resp, err := next(req)
+			if err != nil || resp.StatusCode != http.StatusUnauthorized || !rewindable(req) {
+				return resp, err
+			}
+			Close(resp.Body)
+			cache.Invalidate(token)
+			if token, err = cache.Token(req.Context()); err != nil {
+				return nil, err
+			}
+			if req, err = rewind(req); err != nil {
+				return nil, err
+			}
+			req.Header.Set("Authorization", token.Authorization())
+			return next(req)
+		}
+	}
+}
+
+func rewindable(req *http.Request) bool {
+	return req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+}
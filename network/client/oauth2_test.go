@@ -0,0 +1,90 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestTokenSource(t *testing.T) {
+	var fetches atomic.Int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /token", func(w http.ResponseWriter, r *http.Request) {
+		id, secret, _ := r.BasicAuth()
+		if id != "id" || secret != "secret" || r.FormValue("grant_type") != "client_credentials" || r.FormValue("scope") != "a b" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		n := fetches.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprintf(w, `{"access_token":"t%d","token_type":"bearer","expires_in":"3600"}`, n)
+	})
+	mux.HandleFunc("/api", func(w http.ResponseWriter, r *http.Request) {
+		// the first token is revoked on the server
+		if r.Header.Get("Authorization") != "Bearer t2" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	source := &ClientCredentials{
+		TokenURL:     server.URL + "/token",
+		ClientID:     "id",
+		ClientSecret: "secret",
+		Scopes:       []string{"a", "b"},
+	}
+	c := New(WithTokenSource(source))
+	var wg sync.WaitGroup
+	errs := make(chan error, 8)
+	for range 8 {
+		wg.Go(func() {
+			body, err := c.Get(context.Background(), server.URL+"/api")
+			if err == nil && string(body) != "ok" {
+				err = fmt.Errorf("body = %q, want ok", body)
+			}
+			errs <- err
+		})
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("Get() error = %v", err)
+		}
+	}
+	if fetches.Load() != 2 {
+		t.Fatalf("token fetches = %d, want 2", fetches.Load())
+	}
+}
+
+func TestRefreshTokenRotation(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.FormValue("grant_type") != "refresh_token" || r.FormValue("refresh_token") != "r1" || r.FormValue("client_id") != "id" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"access_token":"a","refresh_token":"r2","expires_in":60}`))
+	}))
+	defer server.Close()
+
+	source := NewRefreshToken(server.URL, "id", "", "r1")
+	source.CredentialsInBody = true
+	token, err := source.Token(context.Background())
+	if err != nil {
+		t.Fatalf("Token() error = %v", err)
+	}
+	if token.Authorization() != "Bearer a" || token.Expiry.IsZero() {
+		t.Fatalf("token = %+v", token)
+	}
+	if source.CurrentRefreshToken() != "r2" {
+		t.Fatalf("refresh token = %q, want r2", source.CurrentRefreshToken())
+	}
+}
@@ -0,0 +1,320 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/sunls24/gox/network/header"
+	"golang.org/x/net/proxy"
+)
+
+// OrderedTransport an HTTP/1.1 transport that writes headers in the order
+// carried by header.ContextWithOrder, names not listed follow sorted. Host
+// comes first unless the order lists it. Every request uses a new connection,
+// through an HTTP CONNECT or SOCKS5 tunnel when Proxy returns a proxy.
+//
+// With HTTP2 set, h2 is offered over TLS and, when the server picks it, the
+// request is sent as HTTP/2 with the pseudo-headers in Order.Pseudo, which
+// defaults to :method, :authority, :scheme, :path as browsers send them.
+//
+// The client stores the order of WithHeader and per call headers
+// automatically, use it with WithTransport(&OrderedTransport{}).
+type OrderedTransport struct {
+	Dialer    *net.Dialer
+	TLSConfig *tls.Config
+	// Proxy same as http.Transport.Proxy, set by WithProxy and WithProxyPool
+	Proxy func(*http.Request) (*url.URL, error)
+	HTTP2 bool
+}
+
+func (t *OrderedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.roundTrip(req)
+	if err != nil && req.Body != nil {
+		_ = req.Body.Close()
+	}
+	return resp, err
+}
+
+func (t *OrderedTransport) roundTrip(req *http.Request) (*http.Response, error) {
+	if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+		return nil, fmt.Errorf("ordered transport: unsupported scheme %q", req.URL.Scheme)
+	}
+	if order, ok := header.OrderFromContext(req.Context()); ok {
+		if err := order.Validate(); err != nil {
+			return nil, fmt.Errorf("ordered transport: %w", err)
+		}
+	}
+	ctx := req.Context()
+	conn, err := t.dial(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	stop := context.AfterFunc(ctx, func() { _ = conn.Close() })
+	fail := func(err error) (*http.Response, error) {
+		stop()
+		_ = conn.Close()
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, err
+	}
+
+	var resp *http.Response
+	if tlsConn, ok := conn.(*tls.Conn); ok && tlsConn.ConnectionState().NegotiatedProtocol == "h2" {
+		resp, err = roundTripH2(conn, req)
+	} else {
+		resp, err = roundTripH1(conn, req)
+	}
+	if err != nil {
+		return fail(err)
+	}
+	resp.Body = &connBody{ReadCloser: resp.Body, conn: conn, stop: stop}
+	return resp, nil
+}
+
+func roundTripH1(conn net.Conn, req *http.Request) (*http.Response, error) {
+	writer := bufio.NewWriter(conn)
+	if err := writeOrdered(writer, req); err != nil {
+		return nil, err
+	}
+	if err := writer.Flush(); err != nil {
+		return nil, err
+	}
+	reader := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(reader, req)
+	for err == nil && resp.StatusCode >= 100 && resp.StatusCode < 200 && resp.StatusCode != http.StatusSwitchingProtocols {
+		resp, err = http.ReadResponse(reader, req)
+	}
+	return resp, err
+}
+
+func (t *OrderedTransport) dial(ctx context.Context, req *http.Request) (net.Conn, error) {
+	dialer := t.Dialer
+	if dialer == nil {
+		dialer = &net.Dialer{}
+	}
+	u := req.URL
+	host := u.Hostname()
+	address := net.JoinHostPort(host, portOrDefault(u))
+	var proxyURL *url.URL
+	if t.Proxy != nil {
+		var err error
+		if proxyURL, err = t.Proxy(req); err != nil {
+			return nil, err
+		}
+	}
+	var conn net.Conn
+	var err error
+	if proxyURL != nil {
+		conn, err = t.dialProxy(ctx, dialer, proxyURL, address)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", address)
+	}
+	if err != nil || u.Scheme == "http" {
+		return conn, err
+	}
+	config := &tls.Config{}
+	if t.TLSConfig != nil {
+		config = t.TLSConfig.Clone()
+	}
+	if config.ServerName == "" {
+		config.ServerName = host
+	}
+	config.NextProtos = []string{"http/1.1"}
+	if t.HTTP2 {
+		config.NextProtos = []string{"h2", "http/1.1"}
+	}
+	tlsConn := tls.Client(conn, config)
+	if err := tlsConn.HandshakeContext(ctx); err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	return tlsConn, nil
+}
+
+// dialProxy opens a tunnel to address, failures of the proxy are reported with
+// the Op proxyconnect like http.Transport does
+func (t *OrderedTransport) dialProxy(ctx context.Context, dialer *net.Dialer, proxyURL *url.URL, address string) (net.Conn, error) {
+	var conn net.Conn
+	var err error
+	switch proxyURL.Scheme {
+	case "http", "https":
+		return t.dialConnect(ctx, dialer, proxyURL, address)
+	case "socks5", "socks5h":
+		var socks proxy.Dialer
+		if socks, err = proxy.FromURL(proxyURL, dialer); err == nil {
+			conn, err = socks.(proxy.ContextDialer).DialContext(ctx, "tcp", address)
+		}
+	default:
+		err = fmt.Errorf("unsupported proxy scheme %q", proxyURL.Scheme)
+	}
+	if err != nil {
+		return nil, &net.OpError{Op: "proxyconnect", Net: "tcp", Err: err}
+	}
+	return conn, nil
+}
+
+// dialConnect a CONNECT refused for the target, other than 407, is not a
+// failure of the proxy
+func (t *OrderedTransport) dialConnect(ctx context.Context, dialer *net.Dialer, proxyURL *url.URL, address string) (net.Conn, error) {
+	proxyErr := func(err error) error {
+		return &net.OpError{Op: "proxyconnect", Net: "tcp", Err: err}
+	}
+	proxyAddress := net.JoinHostPort(proxyURL.Hostname(), portOrDefault(proxyURL))
+	var conn net.Conn
+	var err error
+	if proxyURL.Scheme == "https" {
+		config := &tls.Config{ServerName: proxyURL.Hostname()}
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: config}).DialContext(ctx, "tcp", proxyAddress)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", proxyAddress)
+	}
+	if err != nil {
+		return nil, proxyErr(err)
+	}
+	stop := context.AfterFunc(ctx, func() { _ = conn.Close() })
+	defer stop()
+
+	connect := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: address},
+		Host:   address,
+		Header: http.Header{},
+	}
+	if user := proxyURL.User; user != nil {
+		password, _ := user.Password()
+		connect.Header.Set("Proxy-Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(user.Username()+":"+password)))
+	}
+	if err := connect.Write(conn); err != nil {
+		_ = conn.Close()
+		return nil, proxyErr(err)
+	}
+	// the server sends nothing after the response until the client speaks, so
+	// the reader cannot buffer bytes of the tunnel
+	resp, err := http.ReadResponse(bufio.NewReader(conn), connect)
+	if err != nil {
+		_ = conn.Close()
+		return nil, proxyErr(err)
+	}
+	_ = resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusProxyAuthRequired:
+		_ = conn.Close()
+		return nil, proxyErr(errors.New(resp.Status))
+	default:
+		_ = conn.Close()
+		return nil, errors.New(resp.Status)
+	}
+	if !stop() {
+		return nil, ctx.Err()
+	}
+	return conn, nil
+}
+
+func portOrDefault(u *url.URL) string {
+	if port := u.Port(); port != "" {
+		return port
+	}
+	return map[string]string{"http": "80", "https": "443", "socks5": "1080", "socks5h": "1080"}[u.Scheme]
+}
+
+func writeOrdered(w *bufio.Writer, req *http.Request) error {
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+	h := req.Header.Clone()
+	if h == nil {
+		h = http.Header{}
+	}
+	h.Set("Host", host)
+	chunked := false
+	hasBody := req.Body != nil && req.Body != http.NoBody
+	switch {
+	case hasBody && req.ContentLength > 0:
+		h.Set("Content-Length", strconv.FormatInt(req.ContentLength, 10))
+	case hasBody:
+		chunked = true
+		h.Set("Transfer-Encoding", "chunked")
+	case req.Method == http.MethodPost || req.Method == http.MethodPut || req.Method == http.MethodPatch:
+		h.Set("Content-Length", "0")
+	}
+
+	var keys []string
+	if order, ok := header.OrderFromContext(req.Context()); ok {
+		keys = slices.Clone(order.Headers)
+	}
+	if !slices.Contains(keys, "Host") {
+		keys = append([]string{"Host"}, keys...)
+	}
+	var rest []string
+	for key := range h {
+		if !slices.Contains(keys, key) {
+			rest = append(rest, key)
+		}
+	}
+	slices.Sort(rest)
+
+	method := req.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+	if _, err := fmt.Fprintf(w, "%s %s HTTP/1.1\r\n", method, req.URL.RequestURI()); err != nil {
+		return err
+	}
+	for _, key := range append(keys, rest...) {
+		for _, value := range h[key] {
+			if strings.ContainsAny(key+value, "\r\n") {
+				return fmt.Errorf("ordered transport: invalid header %q", key)
+			}
+			if _, err := fmt.Fprintf(w, "%s: %s\r\n", key, value); err != nil {
+				return err
+			}
+		}
+	}
+	if _, err := w.WriteString("\r\n"); err != nil {
+		return err
+	}
+	if !hasBody {
+		return nil
+	}
+	defer req.Body.Close()
+	if !chunked {
+		_, err := io.Copy(w, req.Body)
+		return err
+	}
+	chunkedWriter := httputil.NewChunkedWriter(w)
+	if _, err := io.Copy(chunkedWriter, req.Body); err != nil {
+		return err
+	}
+	if err := chunkedWriter.Close(); err != nil {
+		return err
+	}
+	_, err := w.WriteString("\r\n")
+	return err
+}
+
+// connBody closes the connection together with the response body
+type connBody struct {
+	io.ReadCloser
+	conn net.Conn
+	stop func() bool
+}
+
+func (b *connBody) Close() error {
+	b.stop()
+	return errors.Join(b.ReadCloser.Close(), b.conn.Close())
+}
@@ -0,0 +1,348 @@
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/sunls24/gox/network/header"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/hpack"
+)
+
+// defaultPseudoOrder the order Chrome and Firefox send
+var defaultPseudoOrder = []string{":method", ":authority", ":scheme", ":path"}
+
+const (
+	h2StreamID = 1
+	// h2Window the receive window advertised for the stream and the connection
+	h2Window = 1 << 24
+)
+
+// h2Conn a single stream on a connection of its own, frames are read by the
+// goroutine that sends the body or reads the response
+type h2Conn struct {
+	writer     *bufio.Writer
+	framer     *http2.Framer
+	sendWindow int64
+	connWindow int64
+	// initialWindow the stream window the server announced
+	initialWindow int64
+	maxFrame      int
+	resp          *http.Response
+	body          *h2Body
+}
+
+func roundTripH2(conn net.Conn, req *http.Request) (*http.Response, error) {
+	writer := bufio.NewWriter(conn)
+	framer := http2.NewFramer(writer, bufio.NewReader(conn))
+	framer.ReadMetaHeaders = hpack.NewDecoder(4096, nil)
+	c := &h2Conn{
+		writer:        writer,
+		framer:        framer,
+		sendWindow:    65535,
+		connWindow:    65535,
+		initialWindow: 65535,
+		maxFrame:      16384,
+	}
+	if _, err := writer.WriteString(http2.ClientPreface); err != nil {
+		return nil, err
+	}
+	err := framer.WriteSettings(
+		http2.Setting{ID: http2.SettingEnablePush, Val: 0},
+		http2.Setting{ID: http2.SettingInitialWindowSize, Val: h2Window},
+	)
+	if err != nil {
+		return nil, err
+	}
+	if err := framer.WriteWindowUpdate(0, h2Window-65535); err != nil {
+		return nil, err
+	}
+	hasBody := req.Body != nil && req.Body != http.NoBody
+	if err := c.writeHeaders(req, hasBody); err != nil {
+		return nil, err
+	}
+	if hasBody {
+		if err := c.writeBody(req.Body); err != nil {
+			return nil, err
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		return nil, err
+	}
+	for c.resp == nil {
+		if err := c.readFrame(); err != nil {
+			return nil, err
+		}
+	}
+	return c.resp, nil
+}
+
+func (c *h2Conn) writeHeaders(req *http.Request, hasBody bool) error {
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+	method := req.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+	pseudo := map[string]string{
+		":method":    method,
+		":authority": host,
+		":scheme":    req.URL.Scheme,
+		":path":      req.URL.RequestURI(),
+	}
+	pseudoOrder := defaultPseudoOrder
+	var keys []string
+	if order, ok := header.OrderFromContext(req.Context()); ok {
+		keys = slices.Clone(order.Headers)
+		if order.Pseudo != nil {
+			pseudoOrder = order.Pseudo
+		}
+	}
+	h := req.Header.Clone()
+	if h == nil {
+		h = http.Header{}
+	}
+	// connection specific headers are not allowed in HTTP/2
+	for _, key := range []string{"Host", "Connection", "Keep-Alive", "Proxy-Connection", "Transfer-Encoding", "Upgrade", "Te"} {
+		h.Del(key)
+	}
+	if hasBody && req.ContentLength > 0 {
+		h.Set("Content-Length", strconv.FormatInt(req.ContentLength, 10))
+	}
+	var rest []string
+	for key := range h {
+		if !slices.Contains(keys, key) {
+			rest = append(rest, key)
+		}
+	}
+	slices.Sort(rest)
+
+	var block bytes.Buffer
+	encoder := hpack.NewEncoder(&block)
+	for _, name := range pseudoOrder {
+		_ = encoder.WriteField(hpack.HeaderField{Name: name, Value: pseudo[name]})
+	}
+	for _, key := range append(keys, rest...) {
+		for _, value := range h[key] {
+			if strings.ContainsAny(key+value, "\r\n") {
+				return fmt.Errorf("ordered transport: invalid header %q", key)
+			}
+			_ = encoder.WriteField(hpack.HeaderField{Name: strings.ToLower(key), Value: value})
+		}
+	}
+
+	fragment := block.Bytes()
+	first := true
+	for {
+		chunk := fragment[:min(len(fragment), c.maxFrame)]
+		fragment = fragment[len(chunk):]
+		end := len(fragment) == 0
+		var err error
+		if first {
+			err = c.framer.WriteHeaders(http2.HeadersFrameParam{
+				StreamID:      h2StreamID,
+				BlockFragment: chunk,
+				EndStream:     !hasBody,
+				EndHeaders:    end,
+			})
+			first = false
+		} else {
+			err = c.framer.WriteContinuation(h2StreamID, end, chunk)
+		}
+		if err != nil || end {
+			return err
+		}
+	}
+}
+
+// writeBody waits for WINDOW_UPDATE frames when the server window is used up
+func (c *h2Conn) writeBody(body io.ReadCloser) error {
+	defer body.Close()
+	buf := make([]byte, c.maxFrame)
+	for {
+		n, err := io.ReadFull(body, buf)
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			return err
+		}
+		end := err != nil
+		data := buf[:n]
+		for {
+			for len(data) > 0 && min(c.sendWindow, c.connWindow) <= 0 {
+				if err := c.writer.Flush(); err != nil {
+					return err
+				}
+				if err := c.readFrame(); err != nil {
+					return err
+				}
+				if c.resp != nil {
+					// the server answered before reading the whole body
+					return nil
+				}
+			}
+			size := min(int64(len(data)), c.sendWindow, c.connWindow)
+			if err := c.framer.WriteData(h2StreamID, end && int(size) == len(data), data[:size]); err != nil {
+				return err
+			}
+			c.sendWindow -= size
+			c.connWindow -= size
+			if data = data[size:]; len(data) == 0 {
+				break
+			}
+		}
+		if end {
+			return nil
+		}
+	}
+}
+
+// readFrame handles one frame, response headers set resp and data frames are
+// appended to its body
+func (c *h2Conn) readFrame() error {
+	frame, err := c.framer.ReadFrame()
+	if err != nil {
+		return err
+	}
+	switch f := frame.(type) {
+	case *http2.SettingsFrame:
+		if f.IsAck() {
+			return nil
+		}
+		err := f.ForeachSetting(func(s http2.Setting) error {
+			switch s.ID {
+			case http2.SettingInitialWindowSize:
+				c.sendWindow += int64(s.Val) - c.initialWindow
+				c.initialWindow = int64(s.Val)
+			case http2.SettingMaxFrameSize:
+				c.maxFrame = int(s.Val)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		return c.flush(c.framer.WriteSettingsAck())
+	case *http2.PingFrame:
+		if f.IsAck() {
+			return nil
+		}
+		return c.flush(c.framer.WritePing(true, f.Data))
+	case *http2.WindowUpdateFrame:
+		if f.StreamID == 0 {
+			c.connWindow += int64(f.Increment)
+		} else if f.StreamID == h2StreamID {
+			c.sendWindow += int64(f.Increment)
+		}
+	case *http2.GoAwayFrame:
+		if f.LastStreamID < h2StreamID {
+			return fmt.Errorf("http2: server sent GOAWAY, code %v", f.ErrCode)
+		}
+	case *http2.RSTStreamFrame:
+		if f.StreamID == h2StreamID {
+			return fmt.Errorf("http2: stream reset, code %v", f.ErrCode)
+		}
+	case *http2.MetaHeadersFrame:
+		if f.StreamID != h2StreamID {
+			return nil
+		}
+		return c.headers(f)
+	case *http2.DataFrame:
+		if f.StreamID != h2StreamID || c.body == nil {
+			return nil
+		}
+		c.body.buf.Write(f.Data())
+		c.body.done = f.StreamEnded()
+		if n := f.Header().Length; n > 0 {
+			if err := c.framer.WriteWindowUpdate(0, n); err != nil {
+				return err
+			}
+			if !c.body.done {
+				if err := c.framer.WriteWindowUpdate(h2StreamID, n); err != nil {
+					return err
+				}
+			}
+			return c.writer.Flush()
+		}
+	}
+	return nil
+}
+
+func (c *h2Conn) flush(err error) error {
+	if err != nil {
+		return err
+	}
+	return c.writer.Flush()
+}
+
+// headers informational responses are skipped, headers after the response are
+// its trailer
+func (c *h2Conn) headers(f *http2.MetaHeadersFrame) error {
+	if c.resp != nil {
+		c.resp.Trailer = http.Header{}
+		for _, field := range f.RegularFields() {
+			c.resp.Trailer.Add(http.CanonicalHeaderKey(field.Name), field.Value)
+		}
+		c.body.done = true
+		return nil
+	}
+	status, err := strconv.Atoi(f.PseudoValue("status"))
+	if err != nil {
+		return errors.New("http2: response has no valid :status")
+	}
+	if status >= 100 && status < 200 {
+		return nil
+	}
+	h := http.Header{}
+	for _, field := range f.RegularFields() {
+		h.Add(http.CanonicalHeaderKey(field.Name), field.Value)
+	}
+	c.body = &h2Body{conn: c, done: f.StreamEnded()}
+	c.resp = &http.Response{
+		Status:        strconv.Itoa(status) + " " + http.StatusText(status),
+		StatusCode:    status,
+		Proto:         "HTTP/2.0",
+		ProtoMajor:    2,
+		Header:        h,
+		ContentLength: -1,
+		Body:          c.body,
+	}
+	if length, err := strconv.ParseInt(h.Get("Content-Length"), 10, 64); err == nil {
+		c.resp.ContentLength = length
+	}
+	return nil
+}
+
+// h2Body reads frames on demand, the connection is closed by connBody
+type h2Body struct {
+	conn *h2Conn
+	buf  bytes.Buffer
+	done bool
+}
+
+func (b *h2Body) Read(p []byte) (int, error) {
+	for b.buf.Len() == 0 {
+		if b.done {
+			return 0, io.EOF
+		}
+		if err := b.conn.readFrame(); err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return 0, err
+		}
+	}
+	return b.buf.Read(p)
+}
+
+func (b *h2Body) Close() error {
+	return nil
+}
@@ -0,0 +1,162 @@
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/tls"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/sunls24/gox/network/header"
+	"github.com/sunls24/gox/types"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/hpack"
+)
+
+func TestOrderedTransport(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	lines := make(chan []string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		reader := bufio.NewReader(conn)
+		var got []string
+		for {
+			line, err := reader.ReadString('\n')
+			line = strings.TrimRight(line, "\r\n")
+			if err != nil || line == "" {
+				break
+			}
+			got = append(got, line)
+		}
+		lines <- got
+		_, _ = conn.Write([]byte("HTTP/1.1 200 OK\r\nContent-Length: 2\r\n\r\nok"))
+	}()
+
+	c := New(WithTransport(&OrderedTransport{}), WithHeader(types.NewPair("User-Agent", "test")))
+	req, _ := http.NewRequestWithContext(context.Background(), http.MethodGet, "http://"+listener.Addr().String()+"/a?b=c", nil)
+	req.Header.Set("X-Extra", "1")
+	body, err := c.Do(req,
+		types.NewPair("sec-ch-ua", "x"),
+		types.NewPair("Accept", "*/*"),
+		types.NewPair("Cookie", "a=1"),
+		types.NewPair("Cookie", "b=2"),
+	)
+	if err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+	if string(body) != "ok" {
+		t.Fatalf("body = %q, want ok", body)
+	}
+	want := []string{
+		"GET /a?b=c HTTP/1.1",
+		"Host: " + listener.Addr().String(),
+		"User-Agent: test",
+		"Sec-Ch-Ua: x",
+		"Accept: */*",
+		"Cookie: a=1",
+		"Cookie: b=2",
+	}
+	got := <-lines
+	if !slices.Equal(got[:len(want)], want) {
+		t.Fatalf("request = %q, want prefix %q", got, want)
+	}
+	if !slices.Contains(got[len(want):], "X-Extra: 1") {
+		t.Fatalf("request = %q, want X-Extra after ordered headers", got)
+	}
+}
+
+func TestOrderedTransportHTTP2(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		_, _ = w.Write([]byte(r.Proto + " "))
+		_, _ = w.Write(body)
+	}))
+	server.EnableHTTP2 = true
+	server.StartTLS()
+	defer server.Close()
+
+	c := New(WithTransport(&OrderedTransport{HTTP2: true, TLSConfig: &tls.Config{InsecureSkipVerify: true}}))
+	// larger than the initial flow control window of 64KB
+	data := strings.Repeat("0123456789", 20000)
+	body, err := c.Post(context.Background(), server.URL, strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("Post() error = %v", err)
+	}
+	if string(body) != "HTTP/2.0 "+data {
+		t.Fatalf("body = %.20q... %d bytes, want HTTP/2.0 echo", body, len(body))
+	}
+}
+
+func TestOrderedTransportPseudoOrder(t *testing.T) {
+	cert := httptest.NewTLSServer(http.NotFoundHandler())
+	config := &tls.Config{Certificates: cert.TLS.Certificates, NextProtos: []string{"h2"}}
+	cert.Close()
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	names := make(chan []string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		preface := make([]byte, len(http2.ClientPreface))
+		if _, err := io.ReadFull(conn, preface); err != nil {
+			return
+		}
+		framer := http2.NewFramer(conn, conn)
+		framer.ReadMetaHeaders = hpack.NewDecoder(4096, nil)
+		_ = framer.WriteSettings()
+		for {
+			frame, err := framer.ReadFrame()
+			if err != nil {
+				return
+			}
+			if f, ok := frame.(*http2.MetaHeadersFrame); ok {
+				var got []string
+				for _, field := range f.Fields {
+					got = append(got, field.Name)
+				}
+				names <- got
+				var block bytes.Buffer
+				_ = hpack.NewEncoder(&block).WriteField(hpack.HeaderField{Name: ":status", Value: "204"})
+				_ = framer.WriteHeaders(http2.HeadersFrameParam{StreamID: f.StreamID, BlockFragment: block.Bytes(), EndStream: true, EndHeaders: true})
+				return
+			}
+		}
+	}()
+
+	order := header.New().Add(types.NewPair("User-Agent", "test"), types.NewPair("Accept", "*/*")).
+		PseudoHeaderOrder(":method", ":path", ":authority", ":scheme").Order()
+	ctx := header.ContextWithOrder(context.Background(), order)
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "https://"+listener.Addr().String()+"/", nil)
+	req.Header.Set("Accept", "*/*")
+	req.Header.Set("User-Agent", "test")
+	transport := &OrderedTransport{HTTP2: true, TLSConfig: &tls.Config{InsecureSkipVerify: true}}
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("RoundTrip() error = %v", err)
+	}
+	_ = resp.Body.Close()
+	want := []string{":method", ":path", ":authority", ":scheme", "user-agent", "accept"}
+	if got := <-names; !slices.Equal(got, want) || resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("fields = %v, status = %d, want %v", got, resp.StatusCode, want)
+	}
+}
@@ -0,0 +1,207 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+type ProxyMode int
+
+const (
+	// RotatePerRequest picks the next healthy proxy for every request
+	RotatePerRequest ProxyMode = iota
+	// RotatePerHost keeps using the same proxy for a host until it fails
+	RotatePerHost
+)
+
+// WithProxy proxy scheme can be http, https or socks5, credentials are taken
+// from the URL user info. The proxy is applied after all options to a copy of
+// the http.Client, it works with *http.Transport and OrderedTransport. Other
+// transports fail every request rather than sending traffic directly.
+func WithProxy(proxy *url.URL) Option {
+	return func(c *Client) {
+		c.proxy = proxy
+	}
+}
+
+// WithProxyPool see WithProxy, it cannot be combined with WithProxy
+func WithProxyPool(pool *ProxyPool) Option {
+	return func(c *Client) {
+		c.proxies = pool
+	}
+}
+
+// applyProxy copies the http.Client, WithClient may share it with the caller
+func (c *Client) applyProxy() {
+	if c.proxy == nil && c.proxies == nil {
+		return
+	}
+	if c.proxy != nil && c.proxies != nil {
+		c.proxyErr = errors.New("client: WithProxy and WithProxyPool cannot be combined")
+		return
+	}
+	proxy := http.ProxyURL(c.proxy)
+	if c.proxies != nil {
+		proxy = proxyFromContext
+	}
+	client := *c.client
+	switch t := client.Transport.(type) {
+	case nil:
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.Proxy = proxy
+		client.Transport = transport
+	case *http.Transport:
+		transport := t.Clone()
+		transport.Proxy = proxy
+		client.Transport = transport
+	case *OrderedTransport:
+		transport := *t
+		transport.Proxy = proxy
+		client.Transport = &transport
+	default:
+		c.proxyErr = fmt.Errorf("client: proxy is not supported by %T", t)
+		return
+	}
+	c.client = &client
+}
+
+type proxyKey struct {
+}
+
+func proxyFromContext(req *http.Request) (*url.URL, error) {
+	if p, ok := req.Context().Value(proxyKey{}).(*poolProxy); ok {
+		return p.url, nil
+	}
+	return nil, nil
+}
+
+type ProxyPool struct {
+	mode ProxyMode
+	// MaxFails consecutive failures before a proxy is taken out of rotation
+	MaxFails int
+	// CoolDown how long a failing proxy stays out of rotation
+	CoolDown time.Duration
+
+	m       sync.Mutex
+	proxies []*poolProxy
+	next    int
+	hosts   map[string]*poolProxy
+}
+
+type poolProxy struct {
+	url       *url.URL
+	fails     int
+	downUntil time.Time
+}
+
+func NewProxyPool(mode ProxyMode, proxies ...string) (*ProxyPool, error) {
+	if len(proxies) == 0 {
+		return nil, errors.New("proxy pool is empty")
+	}
+	pool := &ProxyPool{
+		mode:     mode,
+		MaxFails: 3,
+		CoolDown: time.Minute,
+		hosts:    make(map[string]*poolProxy),
+	}
+	for _, raw := range proxies {
+		u, err := url.Parse(strings.TrimSpace(raw))
+		if err != nil {
+			return nil, err
+		}
+		if u.Host == "" {
+			return nil, errors.New("proxy host is required: " + u.Redacted())
+		}
+		pool.proxies = append(pool.proxies, &poolProxy{url: u})
+	}
+	return pool, nil
+}
+
+// Healthy returns the proxies currently in rotation
+func (p *ProxyPool) Healthy() []*url.URL {
+	p.m.Lock()
+	defer p.m.Unlock()
+	now := time.Now()
+	var list []*url.URL
+	for _, proxy := range p.proxies {
+		if proxy.healthy(now) {
+			list = append(list, proxy.url)
+		}
+	}
+	return list
+}
+
+// pick falls back to the proxy that recovers first when none is healthy
+func (p *ProxyPool) pick(host string) *poolProxy {
+	p.m.Lock()
+	defer p.m.Unlock()
+	now := time.Now()
+	if p.mode == RotatePerHost {
+		if proxy, ok := p.hosts[host]; ok && proxy.healthy(now) {
+			return proxy
+		}
+	}
+	var fallback *poolProxy
+	for range p.proxies {
+		proxy := p.proxies[p.next]
+		p.next = (p.next + 1) % len(p.proxies)
+		if proxy.healthy(now) {
+			fallback = proxy
+			break
+		}
+		if fallback == nil || proxy.downUntil.Before(fallback.downUntil) {
+			fallback = proxy
+		}
+	}
+	if p.mode == RotatePerHost {
+		p.hosts[host] = fallback
+	}
+	return fallback
+}
+
+func (p *ProxyPool) report(proxy *poolProxy, failed bool) {
+	p.m.Lock()
+	defer p.m.Unlock()
+	if !failed {
+		proxy.fails = 0
+		return
+	}
+	if proxy.fails++; proxy.fails >= p.MaxFails {
+		proxy.fails = 0
+		proxy.downUntil = time.Now().Add(p.CoolDown)
+	}
+}
+
+// proxyFailure reports errors caused by the proxy rather than the target host
+func proxyFailure(resp *http.Response, err error) bool {
+	if err == nil {
+		return resp.StatusCode == http.StatusProxyAuthRequired
+	}
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "proxyconnect"
+}
+
+func (p *poolProxy) healthy(now time.Time) bool {
+	return !now.Before(p.downUntil)
+}
+
+func (p *ProxyPool) roundTrip(req *http.Request, next RoundTrip) (*http.Response, error) {
+	proxy := p.pick(strings.ToLower(req.URL.Hostname()))
+	ctx := req.Context()
+	resp, err := next(req.WithContext(context.WithValue(ctx, proxyKey{}, proxy)))
+	if err != nil && ctx.Err() != nil {
+		return nil, err
+	}
+	// failures of the target host say nothing about the proxy
+	if failed := proxyFailure(resp, err); failed || err == nil {
+		p.report(proxy, failed)
+	}
+	return resp, err
+}
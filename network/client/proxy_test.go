@@ -0,0 +1,194 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sunls24/gox/types"
+)
+
+func TestProxyPool(t *testing.T) {
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("proxied " + r.URL.Host))
+	}))
+	defer proxy.Close()
+	dead := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	dead.Close()
+
+	pool, err := NewProxyPool(RotatePerRequest, dead.URL, proxy.URL)
+	if err != nil {
+		t.Fatalf("NewProxyPool() error = %v", err)
+	}
+	pool.MaxFails = 1
+	c := New(WithProxyPool(pool))
+	if _, err := c.Get(context.Background(), "http://upstream.example.com"); err == nil {
+		t.Fatal("Get() through dead proxy error = nil")
+	}
+	if healthy := pool.Healthy(); len(healthy) != 1 || healthy[0].Host != proxy.Listener.Addr().String() {
+		t.Fatalf("healthy = %v, want only the live proxy", healthy)
+	}
+	for range 2 {
+		body, err := c.Get(context.Background(), "http://upstream.example.com")
+		if err != nil {
+			t.Fatalf("Get() error = %v", err)
+		}
+		if string(body) != "proxied upstream.example.com" {
+			t.Fatalf("body = %q", body)
+		}
+	}
+}
+
+func TestProxyAppliedAfterOptions(t *testing.T) {
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("proxied"))
+	}))
+	defer proxy.Close()
+	u, _ := url.Parse(proxy.URL)
+
+	c := New(WithProxy(u), WithTransport(&http.Transport{}))
+	body, err := c.Get(context.Background(), "http://upstream.example.com")
+	if err != nil || string(body) != "proxied" {
+		t.Fatalf("Get() = %q, %v, want proxied", body, err)
+	}
+
+	hc := &http.Client{}
+	New(WithClient(hc), WithProxy(u))
+	if hc.Transport != nil {
+		t.Fatalf("WithClient transport = %T, want the caller's client untouched", hc.Transport)
+	}
+
+	c = New(WithTransport(roundTripperFunc(http.DefaultTransport.RoundTrip)), WithProxy(u))
+	if _, err := c.Get(context.Background(), "http://upstream.example.com"); err == nil || !strings.Contains(err.Error(), "proxy is not supported") {
+		t.Fatalf("Get() error = %v, want proxy is not supported", err)
+	}
+}
+
+type roundTripperFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestProxyPoolIgnoresTargetFailures(t *testing.T) {
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(time.Millisecond * 50)
+	}))
+	defer proxy.Close()
+	pool, _ := NewProxyPool(RotatePerRequest, proxy.URL)
+	pool.MaxFails = 1
+	c := New(WithProxyPool(pool))
+	c.SetTimeout(time.Millisecond * 10)
+	if _, err := c.Get(context.Background(), "http://slow.example.com"); err == nil {
+		t.Fatal("Get() error = nil, want timeout")
+	}
+	if healthy := pool.Healthy(); len(healthy) != 1 {
+		t.Fatalf("healthy = %v, want the proxy kept after a target timeout", healthy)
+	}
+}
+
+func TestOrderedTransportProxy(t *testing.T) {
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok " + r.Header.Get("X-Id")))
+	}))
+	defer target.Close()
+	connect := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodConnect || r.Header.Get("Proxy-Authorization") != "Basic dTpw" {
+			w.WriteHeader(http.StatusProxyAuthRequired)
+			return
+		}
+		upstream, err := net.Dial("tcp", r.Host)
+		if err != nil {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		conn, _, _ := http.NewResponseController(w).Hijack()
+		_, _ = conn.Write([]byte("HTTP/1.1 200 OK\r\n\r\n"))
+		tunnel(conn, upstream)
+	}))
+	defer connect.Close()
+	socks := socks5Server(t)
+
+	for _, raw := range []string{"http://u:p@" + connect.Listener.Addr().String(), "socks5://" + socks} {
+		u, _ := url.Parse(raw)
+		c := New(WithTransport(&OrderedTransport{}), WithProxy(u))
+		body, err := c.Get(context.Background(), target.URL, types.NewPair("X-Id", "1"))
+		if err != nil || string(body) != "ok 1" {
+			t.Fatalf("Get() through %s = %q, %v, want ok 1", u.Scheme, body, err)
+		}
+	}
+
+	u, _ := url.Parse("http://" + connect.Listener.Addr().String())
+	_, err := New(WithTransport(&OrderedTransport{}), WithProxy(u)).Get(context.Background(), target.URL)
+	if !proxyFailure(nil, err) {
+		t.Fatalf("Get() error = %v, want proxyconnect for 407", err)
+	}
+}
+
+func tunnel(a, b net.Conn) {
+	go func() {
+		_, _ = io.Copy(a, b)
+		_ = a.Close()
+	}()
+	_, _ = io.Copy(b, a)
+	_ = b.Close()
+}
+
+// socks5Server accepts CONNECT without authentication
+func socks5Server(t *testing.T) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				buf := make([]byte, 262)
+				// greeting, then VER CMD RSV ATYP, address and port
+				if _, err := io.ReadFull(conn, buf[:2]); err != nil {
+					return
+				}
+				if _, err := io.ReadFull(conn, buf[:buf[1]]); err != nil {
+					return
+				}
+				_, _ = conn.Write([]byte{5, 0})
+				if _, err := io.ReadFull(conn, buf[:4]); err != nil {
+					return
+				}
+				var host string
+				switch buf[3] {
+				case 1:
+					_, _ = io.ReadFull(conn, buf[:4])
+					host = net.IP(buf[:4]).String()
+				case 3:
+					_, _ = io.ReadFull(conn, buf[:1])
+					n := buf[0]
+					_, _ = io.ReadFull(conn, buf[:n])
+					host = string(buf[:n])
+				}
+				_, _ = io.ReadFull(conn, buf[:2])
+				port := int(buf[0])<<8 | int(buf[1])
+				upstream, err := net.Dial("tcp", net.JoinHostPort(host, strconv.Itoa(port)))
+				if err != nil {
+					_ = conn.Close()
+					return
+				}
+				_, _ = conn.Write([]byte{5, 0, 0, 1, 0, 0, 0, 0, 0, 0})
+				tunnel(conn, upstream)
+			}()
+		}
+	}()
+	return listener.Addr().String()
+}
@@ -0,0 +1,144 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Query encodes a struct into url.Values using `query:"name,omitempty"` tags,
+// untagged fields use the field name and "-" skips a field. Slices become
+// repeated keys, nil pointers are skipped and time.Time is RFC 3339.
+func Query(v any) (url.Values, error) {
+	values := url.Values{}
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return values, nil
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("query: want struct, got %s", rv.Kind())
+	}
+	if err := encodeQuery(values, rv); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// AppendQuery adds the encoded query to rawURL, keeping its existing parameters
+func AppendQuery(rawURL string, query any) (string, error) {
+	values, err := Query(query)
+	if err != nil {
+		return "", err
+	}
+	if len(values) == 0 {
+		return rawURL, nil
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+	merged := u.Query()
+	for k, v := range values {
+		merged[k] = append(merged[k], v...)
+	}
+	u.RawQuery = merged.Encode()
+	return u.String(), nil
+}
+
+func encodeQuery(values url.Values, rv reflect.Value) error {
+	rt := rv.Type()
+	for i := range rt.NumField() {
+		field := rt.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		tag := field.Tag.Get("query")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		fv := rv.Field(i)
+		if field.Anonymous && name == "" && indirect(fv).Kind() == reflect.Struct && indirect(fv).Type() != timeType {
+			if fv = indirect(fv); fv.IsValid() {
+				if err := encodeQuery(values, fv); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		if opts == "omitempty" && fv.IsZero() {
+			continue
+		}
+		if err := addQuery(values, name, fv); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+var timeType = reflect.TypeFor[time.Time]()
+
+func indirect(v reflect.Value) reflect.Value {
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+		v = v.Elem()
+	}
+	return v
+}
+
+func addQuery(values url.Values, name string, v reflect.Value) error {
+	v = indirect(v)
+	if !v.IsValid() {
+		return nil
+	}
+	if v.Kind() == reflect.Slice && v.Type().Elem().Kind() != reflect.Uint8 || v.Kind() == reflect.Array {
+		for i := range v.Len() {
+			if err := addQuery(values, name, v.Index(i)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	s, err := queryString(v)
+	if err != nil {
+		return fmt.Errorf("query %s: %w", name, err)
+	}
+	values.Add(name, s)
+	return nil
+}
+
+func queryString(v reflect.Value) (string, error) {
+	if v.Type() == timeType {
+		return v.Interface().(time.Time).Format(time.RFC3339), nil
+	}
+	if s, ok := v.Interface().(fmt.Stringer); ok {
+		return s.String(), nil
+	}
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, v.Type().Bits()), nil
+	case reflect.Slice:
+		return string(v.Bytes()), nil
+	}
+	return "", errors.New("unsupported type " + v.Type().String())
+}
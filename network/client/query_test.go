@@ -0,0 +1,65 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/sunls24/gox/types"
+)
+
+func TestQuery(t *testing.T) {
+	type Page struct {
+		Page int `query:"page,omitempty"`
+	}
+	type params struct {
+		Page
+		Symbols []string  `query:"symbol"`
+		Since   time.Time `query:"since"`
+		Limit   *int      `query:"limit"`
+		Skip    string    `query:"-"`
+		Base    string
+	}
+	values, err := Query(params{
+		Page:    Page{Page: 2},
+		Symbols: []string{"USD", "EUR"},
+		Since:   time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC),
+		Skip:    "x",
+		Base:    "CNY",
+	})
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	if want := "Base=CNY&page=2&since=2026-10-19T00%3A00%3A00Z&symbol=USD&symbol=EUR"; values.Encode() != want {
+		t.Fatalf("Query() = %q, want %q", values.Encode(), want)
+	}
+}
+
+func TestBaseURLAndHeader(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.URL.String() + "|" + r.Header.Get("X-Key") + "|" + r.Header.Get("X-Default")))
+	}))
+	defer server.Close()
+
+	c := New(
+		WithBaseURL(server.URL+"/v1/?token=t"),
+		WithHeader(types.NewPair("X-Key", "default"), types.NewPair("X-Default", "yes")),
+	)
+	body, err := c.Get(context.Background(), "/rates?base=CNY", types.NewPair("X-Key", "call"))
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if want := "/v1/rates?base=CNY&token=t|call|yes"; string(body) != want {
+		t.Fatalf("body = %q, want %q", body, want)
+	}
+
+	body, err = c.Get(context.Background(), "/files/a%2Fb%20c")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if want := "/v1/files/a%2Fb%20c?token=t|default|yes"; string(body) != want {
+		t.Fatalf("body = %q, want %q", body, want)
+	}
+}
@@ -0,0 +1,47 @@
+package client
+
+import (
+	"net/http"
+	"strings"
+
+	"golang.org/x/time/rate"
+)
+
+type rateLimiter struct {
+	global *rate.Limiter
+	hosts  map[string]*rate.Limiter
+}
+
+// WithRateLimit limits all requests of the client, waiting until the request
+// context is done
+func WithRateLimit(limit rate.Limit, burst int) Option {
+	return func(c *Client) {
+		c.rateLimiter().global = rate.NewLimiter(limit, burst)
+	}
+}
+
+// WithHostRateLimit host is matched against URL.Hostname, case-insensitive
+func WithHostRateLimit(host string, limit rate.Limit, burst int) Option {
+	return func(c *Client) {
+		c.rateLimiter().hosts[strings.ToLower(host)] = rate.NewLimiter(limit, burst)
+	}
+}
+
+func (c *Client) rateLimiter() *rateLimiter {
+	if c.limiter == nil {
+		c.limiter = &rateLimiter{hosts: make(map[string]*rate.Limiter)}
+	}
+	return c.limiter
+}
+
+func (l *rateLimiter) wait(req *http.Request) error {
+	if l.global != nil {
+		if err := l.global.Wait(req.Context()); err != nil {
+			return err
+		}
+	}
+	if limiter, ok := l.hosts[strings.ToLower(req.URL.Hostname())]; ok {
+		return limiter.Wait(req.Context())
+	}
+	return nil
+}
@@ -0,0 +1,28 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+func TestHostRateLimit(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	c := New(WithHostRateLimit("127.0.0.1", rate.Every(time.Hour), 1))
+	if _, err := c.Get(context.Background(), server.URL); err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*50)
+	defer cancel()
+	_, err := c.Get(ctx, server.URL)
+	if err == nil || errors.Is(err, context.Canceled) {
+		t.Fatalf("Get() error = %v, want rate limit wait error", err)
+	}
+}
@@ -9,7 +9,7 @@ import (
 )
 
 func (c *Client) Get(ctx context.Context, url string, header ...types.Pair[string]) ([]byte, error) {
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	req, err := NewRequest(ctx, http.MethodGet, url, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -21,7 +21,7 @@ func Get(ctx context.Context, url string, header ...types.Pair[string]) ([]byte,
 }
 
 func (c *Client) Post(ctx context.Context, url string, body any, header ...types.Pair[string]) ([]byte, error) {
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, NewBody(body))
+	req, err := NewRequest(ctx, http.MethodPost, url, body)
 	if err != nil {
 		return nil, err
 	}
@@ -33,7 +33,7 @@ func Post(ctx context.Context, url string, body any, header ...types.Pair[string
 }
 
 func (c *Client) PostReader(ctx context.Context, url string, body any, header ...types.Pair[string]) (io.ReadCloser, error) {
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, NewBody(body))
+	req, err := NewRequest(ctx, http.MethodPost, url, body)
 	if err != nil {
 		return nil, err
 	}
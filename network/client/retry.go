@@ -0,0 +1,170 @@
+package client
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+var DefaultRetryStatusCodes = []int{
+	http.StatusTooManyRequests,
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusGatewayTimeout,
+}
+
+type Retry struct {
+	// MaxAttempts includes the first attempt, values below 2 disable retrying
+	MaxAttempts int
+	MinBackoff  time.Duration
+	MaxBackoff  time.Duration
+	// StatusCodes defaults to DefaultRetryStatusCodes
+	StatusCodes []int
+}
+
+func WithRetry(retry Retry) Option {
+	return func(c *Client) {
+		if retry.MinBackoff <= 0 {
+			retry.MinBackoff = time.Millisecond * 200
+		}
+		if retry.MaxBackoff < retry.MinBackoff {
+			retry.MaxBackoff = max(time.Second*30, retry.MinBackoff)
+		}
+		if retry.StatusCodes == nil {
+			retry.StatusCodes = DefaultRetryStatusCodes
+		}
+		c.retry = &retry
+	}
+}
+
+func (c *Client) send(req *http.Request) (*http.Response, error) {
+	if c.retry == nil || c.retry.MaxAttempts < 2 || !replayable(req) {
+		return c.roundTrip(req)
+	}
+	ctx := req.Context()
+	// every attempt starts from a clone of the original request, the http.Client
+	// adds jar cookies to the headers of the request it sends
+	next := req.Clone(ctx)
+	for attempt := 1; ; attempt++ {
+		resp, err := c.roundTrip(next)
+		last := attempt >= c.retry.MaxAttempts
+		if err != nil {
+			if last || !retryableError(ctx, err) {
+				return nil, err
+			}
+		} else if last || !slices.Contains(c.retry.StatusCodes, resp.StatusCode) {
+			return resp, nil
+		}
+
+		wait := c.retry.backoff(attempt)
+		if resp != nil {
+			if after, ok := retryAfter(resp.Header.Get("Retry-After")); ok {
+				wait = min(after, c.retry.MaxBackoff)
+			}
+			Close(resp.Body)
+		}
+		if err := sleep(ctx, wait); err != nil {
+			return nil, err
+		}
+		if next, err = rewind(req); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// backoff is exponential with equal jitter: half of the delay is fixed, the
+// other half random
+func (r *Retry) backoff(attempt int) time.Duration {
+	d := r.MinBackoff << min(attempt-1, 30)
+	if d <= 0 || d > r.MaxBackoff {
+		d = r.MaxBackoff
+	}
+	half := d / 2
+	return half + rand.N(half+1)
+}
+
+func replayable(req *http.Request) bool {
+	if req.Body == nil || req.Body == http.NoBody {
+		return idempotent(req.Method)
+	}
+	return req.GetBody != nil
+}
+
+func idempotent(method string) bool {
+	switch method {
+	case "", http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// rewind clones req with a fresh body
+func rewind(req *http.Request) (*http.Request, error) {
+	next := req.Clone(req.Context())
+	if req.GetBody == nil || req.Body == nil || req.Body == http.NoBody {
+		return next, nil
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+	next.Body = body
+	return next, nil
+}
+
+// retryableError only transport failures are retried, errors raised before the
+// request reached the network, such as a bad scheme or a signer error, would
+// fail the same way again
+func retryableError(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	var certErr *tls.CertificateVerificationError
+	if errors.As(err, &certErr) {
+		return false
+	}
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED) {
+		return true
+	}
+	// *url.Error is a net.Error itself, only the error it wraps counts
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		err = urlErr.Err
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+func retryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return max(time.Duration(seconds)*time.Second, 0), true
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		return max(time.Until(t), 0), true
+	}
+	return 0, false
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
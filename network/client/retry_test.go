@@ -0,0 +1,99 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRetryStatus(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	c := New(WithRetry(Retry{MaxAttempts: 3, MinBackoff: time.Millisecond}))
+	body, err := c.Post(context.Background(), server.URL, map[string]string{"a": "b"})
+	if err != nil {
+		t.Fatalf("Post() error = %v", err)
+	}
+	if string(body) != "ok" || calls.Load() != 3 {
+		t.Fatalf("body = %q, calls = %d, want ok after 3 calls", body, calls.Load())
+	}
+}
+
+func TestRetrySkipsUnreplayableBody(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	c := New(WithRetry(Retry{MaxAttempts: 3, MinBackoff: time.Millisecond}))
+	req, _ := http.NewRequest(http.MethodPost, server.URL, strings.NewReader("body"))
+	req.GetBody = nil
+	if _, err := c.Do(req); err == nil {
+		t.Fatal("Do() error = nil, want status error")
+	}
+	if calls.Load() != 1 {
+		t.Fatalf("calls = %d, want 1", calls.Load())
+	}
+}
+
+func TestRetryFreshHeaders(t *testing.T) {
+	var cookies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cookies = append(cookies, r.Header.Get("Cookie"))
+		http.SetCookie(w, &http.Cookie{Name: "s", Value: "1"})
+		if len(cookies) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	c := New(WithJar(), WithRetry(Retry{MaxAttempts: 3, MinBackoff: time.Millisecond}))
+	if _, err := c.Get(context.Background(), server.URL); err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	want := []string{"", "s=1", "s=1"}
+	if strings.Join(cookies, ",") != strings.Join(want, ",") {
+		t.Fatalf("cookies = %q, want %q", cookies, want)
+	}
+}
+
+func TestRetryOnlyTransportErrors(t *testing.T) {
+	var calls atomic.Int32
+	c := New(WithRetry(Retry{MaxAttempts: 3, MinBackoff: time.Millisecond}), WithMiddleware(func(next RoundTrip) RoundTrip {
+		return func(req *http.Request) (*http.Response, error) {
+			calls.Add(1)
+			return next(req)
+		}
+	}))
+	if _, err := c.Get(context.Background(), "htp://example.com"); err == nil {
+		t.Fatal("Get() error = nil, want unsupported protocol scheme")
+	}
+	if calls.Load() != 1 {
+		t.Fatalf("calls = %d, want 1", calls.Load())
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	server.Close()
+	calls.Store(0)
+	if _, err := c.Get(context.Background(), server.URL); err == nil {
+		t.Fatal("Get() error = nil, want connection refused")
+	}
+	if calls.Load() != 3 {
+		t.Fatalf("calls = %d, want 3", calls.Load())
+	}
+}
@@ -0,0 +1,210 @@
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sunls24/gox/types"
+)
+
+const (
+	defaultEventRetry  = time.Second * 3
+	maxEventLine       = 16 << 20
+	maxEventReconnects = 10
+)
+
+var errEventsDone = errors.New("event stream done")
+
+type Event struct {
+	ID    string
+	Event string
+	Data  string
+	// Retry zero when the event has no retry field
+	Retry time.Duration
+}
+
+// EventReader decodes a text/event-stream body
+type EventReader struct {
+	scanner *bufio.Scanner
+	lastID  string
+	retry   time.Duration
+}
+
+func NewEventReader(r io.Reader) *EventReader {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 4096), maxEventLine)
+	scanner.Split(scanEventLines)
+	return &EventReader{scanner: scanner}
+}
+
+// Next returns io.EOF when the stream ends, an unterminated last event is dropped
+func (r *EventReader) Next() (*Event, error) {
+	var (
+		data  strings.Builder
+		event string
+		retry time.Duration
+		ok    bool
+	)
+	for r.scanner.Scan() {
+		line := r.scanner.Text()
+		if line == "" {
+			if !ok {
+				event, retry = "", 0
+				continue
+			}
+			if event == "" {
+				event = "message"
+			}
+			return &Event{
+				ID:    r.lastID,
+				Event: event,
+				Data:  strings.TrimSuffix(data.String(), "\n"),
+				Retry: retry,
+			}, nil
+		}
+		if strings.HasPrefix(line, ":") {
+			continue
+		}
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "event":
+			event = value
+		case "data":
+			data.WriteString(value)
+			data.WriteByte('\n')
+			ok = true
+		case "id":
+			if !strings.ContainsRune(value, 0) {
+				r.lastID = value
+			}
+		case "retry":
+			if ms, err := strconv.ParseUint(value, 10, 32); err == nil {
+				retry = time.Duration(ms) * time.Millisecond
+				r.retry = retry
+			}
+		}
+	}
+	if err := r.scanner.Err(); err != nil {
+		return nil, err
+	}
+	return nil, io.EOF
+}
+
+// LastEventID the id to send in Last-Event-ID when reconnecting
+func (r *EventReader) LastEventID() string {
+	return r.lastID
+}
+
+// scanEventLines splits on CRLF, LF or a lone CR
+func scanEventLines(data []byte, atEOF bool) (int, []byte, error) {
+	if atEOF && len(data) == 0 {
+		return 0, nil, nil
+	}
+	if i := bytes.IndexAny(data, "\r\n"); i >= 0 {
+		if data[i] == '\n' {
+			return i + 1, data[:i], nil
+		}
+		if i+1 < len(data) {
+			if data[i+1] == '\n' {
+				return i + 2, data[:i], nil
+			}
+			return i + 1, data[:i], nil
+		}
+		if atEOF {
+			return i + 1, data[:i], nil
+		}
+		return 0, nil, nil
+	}
+	if atEOF {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
+
+// Events reads a server-sent event stream with GET and reconnects with
+// Last-Event-ID when the connection drops, until ctx is done or fn returns an
+// error. Non-2xx responses and HTTP 204 stop the stream. The client
+// WithMaxResponseSize does not apply to the stream.
+func (c *Client) Events(ctx context.Context, url string, fn func(*Event) error, header ...types.Pair[string]) error {
+	var (
+		lastID   string
+		retry    = defaultEventRetry
+		failures int
+	)
+	ctx = withoutResponseLimit(ctx)
+	for {
+		received, err := c.readEvents(ctx, url, &lastID, &retry, fn, header...)
+		if errors.Is(err, errEventsDone) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if received {
+			failures = 0
+		} else if failures++; failures > maxEventReconnects {
+			return errors.New("event stream reconnect limit exceeded")
+		}
+		if err := sleep(ctx, retry); err != nil {
+			return err
+		}
+	}
+}
+
+func Events(ctx context.Context, url string, fn func(*Event) error, header ...types.Pair[string]) error {
+	return def.Events(ctx, url, fn, header...)
+}
+
+// readEvents returns a nil error when the stream should be reconnected
+func (c *Client) readEvents(ctx context.Context, url string, lastID *string, retry *time.Duration, fn func(*Event) error, header ...types.Pair[string]) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	req.Header.Set("Cache-Control", "no-cache")
+	if *lastID != "" {
+		req.Header.Set("Last-Event-ID", *lastID)
+	}
+	resp, err := c.do(req, header...)
+	if err != nil {
+		var statusErr *StatusError
+		if ctx.Err() != nil || errors.As(err, &statusErr) {
+			return false, err
+		}
+		return false, nil
+	}
+	defer Close(resp.Body)
+	if resp.StatusCode == http.StatusNoContent {
+		return false, errEventsDone
+	}
+
+	reader := NewEventReader(resp.Body)
+	reader.lastID = *lastID
+	received := false
+	for {
+		event, err := reader.Next()
+		*lastID = reader.LastEventID()
+		if reader.retry > 0 {
+			*retry = reader.retry
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return received, ctx.Err()
+			}
+			return received, nil
+		}
+		received = true
+		if err := fn(event); err != nil {
+			return received, err
+		}
+	}
+}
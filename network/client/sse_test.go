@@ -0,0 +1,65 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestEventReader(t *testing.T) {
+	stream := ": comment\r\nevent: delta\r\ndata: a\r\ndata:b\r\nid: 1\r\nretry: 1500\r\n\r\ndata\n\n\rdata: c\r\rignored"
+	reader := NewEventReader(strings.NewReader(stream))
+	var events []*Event
+	for {
+		event, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Next() error = %v", err)
+		}
+		events = append(events, event)
+	}
+	if len(events) != 3 {
+		t.Fatalf("events = %d, want 3", len(events))
+	}
+	if e := events[0]; e.Event != "delta" || e.Data != "a\nb" || e.ID != "1" || e.Retry.Milliseconds() != 1500 {
+		t.Fatalf("events[0] = %+v", e)
+	}
+	if e := events[1]; e.Event != "message" || e.Data != "" || e.ID != "1" {
+		t.Fatalf("events[1] = %+v", e)
+	}
+	if e := events[2]; e.Data != "c" {
+		t.Fatalf("events[2] = %+v", e)
+	}
+}
+
+func TestEventsReconnect(t *testing.T) {
+	var lastIDs []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lastIDs = append(lastIDs, r.Header.Get("Last-Event-ID"))
+		if len(lastIDs) > 2 {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write([]byte("retry: 1\nid: " + string(rune('0'+len(lastIDs))) + "\ndata: x\n\n"))
+	}))
+	defer server.Close()
+
+	var count int
+	err := New().Events(context.Background(), server.URL, func(e *Event) error {
+		count++
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Events() error = %v", err)
+	}
+	if count != 2 || strings.Join(lastIDs, ",") != ",1,2" {
+		t.Fatalf("count = %d, last ids = %q", count, lastIDs)
+	}
+}
@@ -0,0 +1,91 @@
+package client
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// TraceContext a W3C trace context, see https://www.w3.org/TR/trace-context/
+type TraceContext struct {
+	TraceID [16]byte
+	SpanID  [8]byte
+	Flags   byte
+	State   string
+}
+
+const sampledFlag = 0x01
+
+type traceKey struct {
+}
+
+func ContextWithTrace(ctx context.Context, trace TraceContext) context.Context {
+	return context.WithValue(ctx, traceKey{}, trace)
+}
+
+func TraceFromContext(ctx context.Context) (TraceContext, bool) {
+	trace, ok := ctx.Value(traceKey{}).(TraceContext)
+	return trace, ok
+}
+
+// ParseTraceparent parses a traceparent header such as an incoming request carries
+func ParseTraceparent(traceparent, tracestate string) (TraceContext, error) {
+	var trace TraceContext
+	parts := strings.Split(strings.TrimSpace(traceparent), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" || parts[0] == "00" && len(parts) != 4 {
+		return trace, errors.New("invalid traceparent")
+	}
+	if len(parts[1]) != 32 || len(parts[2]) != 16 || len(parts[3]) != 2 {
+		return trace, errors.New("invalid traceparent")
+	}
+	var flags [1]byte
+	if _, err := hex.Decode(trace.TraceID[:], []byte(parts[1])); err != nil {
+		return trace, errors.New("invalid traceparent trace id")
+	}
+	if _, err := hex.Decode(trace.SpanID[:], []byte(parts[2])); err != nil {
+		return trace, errors.New("invalid traceparent parent id")
+	}
+	if _, err := hex.Decode(flags[:], []byte(parts[3])); err != nil {
+		return trace, errors.New("invalid traceparent flags")
+	}
+	if trace.TraceID == [16]byte{} || trace.SpanID == [8]byte{} {
+		return trace, errors.New("invalid traceparent zero id")
+	}
+	trace.Flags = flags[0]
+	trace.State = strings.TrimSpace(tracestate)
+	return trace, nil
+}
+
+func (t TraceContext) Traceparent() string {
+	return "00-" + hex.EncodeToString(t.TraceID[:]) + "-" + hex.EncodeToString(t.SpanID[:]) + "-" + hex.EncodeToString([]byte{t.Flags})
+}
+
+// TraceMiddleware sends traceparent and tracestate on every attempt, as a new
+// child span of the trace in the request context or of a new sampled trace. A
+// traceparent set by the caller is sent unchanged.
+func TraceMiddleware() Middleware {
+	return func(next RoundTrip) RoundTrip {
+		return func(req *http.Request) (*http.Response, error) {
+			if req.Header.Get("traceparent") != "" {
+				return next(req)
+			}
+			trace, ok := TraceFromContext(req.Context())
+			if !ok {
+				_, _ = rand.Read(trace.TraceID[:])
+				trace.Flags = sampledFlag
+			}
+			_, _ = rand.Read(trace.SpanID[:])
+			// set on a copy, a retry rewinds the original request and must not
+			// reuse this span
+			req = req.Clone(req.Context())
+			req.Header.Set("traceparent", trace.Traceparent())
+			if trace.State != "" {
+				req.Header.Set("tracestate", trace.State)
+			}
+			return next(req)
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package header
+
+import (
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/sunls24/gox/types"
+)
+
+// Fingerprint a randomized but internally consistent browser identity, generate
+// it once per session and reuse it for every request of that session
+type Fingerprint struct {
+	Browser  string
+	Platform string
+	// Version major version for Chromium and Firefox, full version for Safari
+	Version   string
+	Languages []string
+	profile   *Profile
+}
+
+type weighted[T any] struct {
+	value  T
+	weight int
+}
+
+type browserPlatform struct {
+	browser, platform string
+}
+
+// shares approximate real-world traffic, newest versions first
+var (
+	browserWeights = []weighted[browserPlatform]{
+		{browserPlatform{"chrome", "Windows"}, 35},
+		{browserPlatform{"chrome", "Android"}, 25},
+		{browserPlatform{"chrome", "macOS"}, 12},
+		{browserPlatform{"safari", "iOS"}, 10},
+		{browserPlatform{"edge", "Windows"}, 8},
+		{browserPlatform{"safari", "macOS"}, 4},
+		{browserPlatform{"firefox", "Windows"}, 4},
+		{browserPlatform{"chrome", "Linux"}, 1},
+		{browserPlatform{"firefox", "macOS"}, 1},
+	}
+	chromeVersions = []weighted[string]{
+		{"147", 50}, {"146", 30}, {"145", 15}, {"144", 5},
+	}
+	firefoxVersions = []weighted[string]{
+		{"149.0", 50}, {"148.0", 30}, {"147.0", 20},
+	}
+	// safariVersions value is the Safari version and the iOS version in the UA
+	safariVersions = []weighted[[2]string]{
+		{[2]string{"26.0", "18_6"}, 50}, {[2]string{"18.6", "18_6"}, 30}, {[2]string{"18.5", "18_5"}, 20},
+	}
+	languageWeights = []weighted[[]string]{
+		{[]string{"zh-CN", "zh"}, 45},
+		{[]string{"zh-CN", "zh", "en"}, 30},
+		{[]string{"zh-CN", "zh", "en-US", "en"}, 15},
+		{[]string{"zh-CN", "zh", "zh-TW", "en"}, 5},
+		{[]string{"en-US", "en"}, 5},
+	}
+)
+
+// NewFingerprint the same seed always produces the same fingerprint
+func NewFingerprint(seed uint64) *Fingerprint {
+	r := rand.New(rand.NewPCG(seed, seed^0x9e3779b97f4a7c15))
+	bp := pick(r, browserWeights)
+	f := &Fingerprint{
+		Browser:   bp.browser,
+		Platform:  bp.platform,
+		Languages: slices.Clone(pick(r, languageWeights)),
+	}
+	name := bp.browser + "-" + strings.ToLower(bp.platform)
+	switch bp.browser {
+	case "chrome", "edge":
+		f.Version = pick(r, chromeVersions)
+		brand := "Google Chrome"
+		if bp.browser == "edge" {
+			brand = "Microsoft Edge"
+		}
+		f.profile = chromium(name, brand, f.Version, bp.platform, chromeLanguages(f.Languages))
+	case "firefox":
+		f.Version = pick(r, firefoxVersions)
+		f.profile = firefox(name, f.Version, bp.platform, firefoxLanguages(f.Languages))
+	case "safari":
+		version := pick(r, safariVersions)
+		f.Version = version[0]
+		userAgent := fmt.Sprintf("Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/%s Safari/605.1.15", version[0])
+		if bp.platform == "iOS" {
+			userAgent = fmt.Sprintf("Mozilla/5.0 (iPhone; CPU iPhone OS %s like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/%s Mobile/15E148 Safari/604.1", version[1], version[0])
+		}
+		f.profile = safari(name, userAgent, chromeLanguages(f.Languages))
+	}
+	return f
+}
+
+func RandomFingerprint() *Fingerprint {
+	return NewFingerprint(rand.Uint64())
+}
+
+func (f *Fingerprint) UserAgent() string {
+	for _, p := range f.profile.headers {
+		if p.Key == "User-Agent" {
+			return p.Value
+		}
+	}
+	return ""
+}
+
+func (f *Fingerprint) Headers(kind Kind) []types.Pair[string] {
+	return f.profile.Headers(kind)
+}
+
+func (b *Builder) Fingerprint(f *Fingerprint, kind Kind) *Builder {
+	return b.Add(f.Headers(kind)...)
+}
+
+func pick[T any](r *rand.Rand, list []weighted[T]) T {
+	total := 0
+	for _, w := range list {
+		total += w.weight
+	}
+	n := r.IntN(total)
+	for _, w := range list {
+		if n < w.weight {
+			return w.value
+		}
+		n -= w.weight
+	}
+	return list[len(list)-1].value
+}
+
+// chromeLanguages formats as Chromium and Safari do, q drops by 0.1 per language
+func chromeLanguages(languages []string) string {
+	parts := make([]string, len(languages))
+	for i, language := range languages {
+		parts[i] = language
+		if i > 0 {
+			parts[i] += ";q=" + strconv.FormatFloat(max(1-float64(i)/10, 0.1), 'f', 1, 64)
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+// firefoxLanguages formats as Firefox does, q is 1 - i/n rounded to one decimal
+func firefoxLanguages(languages []string) string {
+	parts := make([]string, len(languages))
+	for i, language := range languages {
+		parts[i] = language
+		if i > 0 {
+			q := math.Round((1-float64(i)/float64(len(languages)))*10) / 10
+			parts[i] += ";q=" + strconv.FormatFloat(q, 'f', 1, 64)
+		}
+	}
+	return strings.Join(parts, ",")
+}
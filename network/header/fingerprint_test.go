@@ -0,0 +1,55 @@
+package header
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFingerprintSeed(t *testing.T) {
+	a, b := NewFingerprint(42), NewFingerprint(42)
+	if a.UserAgent() != b.UserAgent() || a.Browser != b.Browser {
+		t.Fatalf("same seed produced %q and %q", a.UserAgent(), b.UserAgent())
+	}
+	for seed := range uint64(200) {
+		f := NewFingerprint(seed)
+		headers := map[string]string{}
+		for _, p := range f.Headers(Document) {
+			headers[p.Key] = p.Value
+		}
+		ua := headers["User-Agent"]
+		switch f.Browser {
+		case "chrome", "edge":
+			if !strings.Contains(ua, "Chrome/"+f.Version+".0.0.0") || !strings.Contains(headers["Sec-CH-UA"], `"Chromium";v="`+f.Version+`"`) {
+				t.Fatalf("seed %d: inconsistent chromium headers %v", seed, headers)
+			}
+			if mobile := headers["Sec-CH-UA-Mobile"] == "?1"; mobile != (f.Platform == "Android") {
+				t.Fatalf("seed %d: mobile hint %q on %s", seed, headers["Sec-CH-UA-Mobile"], f.Platform)
+			}
+		case "firefox", "safari":
+			if _, ok := headers["Sec-CH-UA"]; ok {
+				t.Fatalf("seed %d: %s sends client hints", seed, f.Browser)
+			}
+		}
+		if !strings.HasPrefix(headers["Accept-Language"], f.Languages[0]) {
+			t.Fatalf("seed %d: Accept-Language %q", seed, headers["Accept-Language"])
+		}
+	}
+}
+
+func TestFirefoxLanguages(t *testing.T) {
+	got := firefoxLanguages([]string{"zh-CN", "zh", "zh-TW", "zh-HK", "en-US", "en"})
+	if got != firefoxLanguage {
+		t.Fatalf("firefoxLanguages() = %q, want %q", got, firefoxLanguage)
+	}
+}
+
+func TestFingerprintLanguagesIsolated(t *testing.T) {
+	a := NewFingerprint(7)
+	want := strings.Join(NewFingerprint(7).Languages, ",")
+	for i := range a.Languages {
+		a.Languages[i] = "xx"
+	}
+	if got := strings.Join(NewFingerprint(7).Languages, ","); got != want {
+		t.Fatalf("languages = %s after editing another fingerprint, want %s", got, want)
+	}
+}
@@ -1,7 +1,9 @@
 package header
 
 import (
+	"encoding/base64"
 	"fmt"
+	"net/textproto"
 	"slices"
 
 	"github.com/sunls24/gox/types"
@@ -30,6 +32,7 @@ var chromeDocumentHeaders = append(
 
 type Builder struct {
 	headers []types.Pair[string]
+	pseudo  []string
 }
 
 func New() *Builder {
@@ -41,6 +44,54 @@ func (b *Builder) Add(headers ...types.Pair[string]) *Builder {
 	return b
 }
 
+// Set replaces every header with the same name, keeping the position of the first
+func (b *Builder) Set(key, value string) *Builder {
+	i := slices.IndexFunc(b.headers, func(p types.Pair[string]) bool {
+		return equalKey(p.Key, key)
+	})
+	if i < 0 {
+		return b.Add(types.NewPair(key, value))
+	}
+	b.headers[i] = types.NewPair(key, value)
+	b.headers = append(b.headers[:i+1], slices.DeleteFunc(b.headers[i+1:], func(p types.Pair[string]) bool {
+		return equalKey(p.Key, key)
+	})...)
+	return b
+}
+
+func (b *Builder) Del(keys ...string) *Builder {
+	b.headers = slices.DeleteFunc(b.headers, func(p types.Pair[string]) bool {
+		return slices.ContainsFunc(keys, func(key string) bool {
+			return equalKey(p.Key, key)
+		})
+	})
+	return b
+}
+
+// Merge applies the headers of others in order, a name present in a later
+// builder replaces all earlier values of it, new names are appended
+func (b *Builder) Merge(others ...*Builder) *Builder {
+	for _, other := range others {
+		var seen []string
+		for _, p := range other.headers {
+			if slices.ContainsFunc(seen, func(key string) bool { return equalKey(key, p.Key) }) {
+				b.Add(p)
+				continue
+			}
+			seen = append(seen, p.Key)
+			b.Set(p.Key, p.Value)
+		}
+		if other.pseudo != nil {
+			b.pseudo = slices.Clone(other.pseudo)
+		}
+	}
+	return b
+}
+
+func equalKey(a, b string) bool {
+	return textproto.CanonicalMIMEHeaderKey(a) == textproto.CanonicalMIMEHeaderKey(b)
+}
+
 func (b *Builder) ChromeHeaders() *Builder {
 	return b.Add(slices.Clone(chromeHeaders)...)
 }
@@ -57,6 +108,17 @@ func (b *Builder) Authorization(token string) *Builder {
 	return b.Add(types.NewPair("Authorization", fmt.Sprintf("Bearer %s", token)))
 }
 
+// BasicAuth RFC 7617 credentials, username must not contain a colon
+func (b *Builder) BasicAuth(username, password string) *Builder {
+	credentials := base64.StdEncoding.EncodeToString([]byte(username + ":" + password))
+	return b.Add(types.NewPair("Authorization", "Basic "+credentials))
+}
+
+// APIKey name is the header carrying the key, such as X-API-Key
+func (b *Builder) APIKey(name, key string) *Builder {
+	return b.Add(types.NewPair(name, key))
+}
+
 func (b *Builder) Referer(referer string) *Builder {
 	return b.Add(types.NewPair("Referer", referer))
 }
@@ -64,3 +126,8 @@ func (b *Builder) Referer(referer string) *Builder {
 func (b *Builder) Get() []types.Pair[string] {
 	return b.headers
 }
+
+// Clone returns an independent copy that can be changed without affecting b
+func (b *Builder) Clone() *Builder {
+	return &Builder{headers: slices.Clone(b.headers), pseudo: slices.Clone(b.pseudo)}
+}
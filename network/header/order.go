@@ -0,0 +1,69 @@
+package header
+
+import (
+	"context"
+	"errors"
+	"net/textproto"
+	"slices"
+
+	"github.com/sunls24/gox/types"
+)
+
+// Order the order headers are written on the wire by client.OrderedTransport.
+// Pseudo is the HTTP/2 pseudo-header order, applied when the transport speaks
+// HTTP/2, the standard library HTTP/2 transport always uses its own.
+type Order struct {
+	Headers []string
+	Pseudo  []string
+}
+
+var pseudoHeaders = []string{":method", ":authority", ":scheme", ":path"}
+
+// PseudoHeaderOrder order must be a permutation of :method, :authority, :scheme and :path
+func (b *Builder) PseudoHeaderOrder(order ...string) *Builder {
+	b.pseudo = order
+	return b
+}
+
+// Order names in the order they were first added
+func (b *Builder) Order() Order {
+	return Order{Headers: Keys(b.headers...), Pseudo: slices.Clone(b.pseudo)}
+}
+
+func (o Order) Validate() error {
+	if o.Pseudo == nil {
+		return nil
+	}
+	if len(o.Pseudo) != len(pseudoHeaders) {
+		return errors.New("pseudo-header order must list all four pseudo-headers")
+	}
+	for _, name := range pseudoHeaders {
+		if !slices.Contains(o.Pseudo, name) {
+			return errors.New("pseudo-header order is missing " + name)
+		}
+	}
+	return nil
+}
+
+// Keys canonical names of headers without duplicates, in order of first appearance
+func Keys(headers ...types.Pair[string]) []string {
+	keys := make([]string, 0, len(headers))
+	for _, p := range headers {
+		if key := textproto.CanonicalMIMEHeaderKey(p.Key); !slices.Contains(keys, key) {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+type orderKey struct {
+}
+
+func ContextWithOrder(ctx context.Context, order Order) context.Context {
+	return context.WithValue(ctx, orderKey{}, order)
+}
+
+func OrderFromContext(ctx context.Context) (Order, bool) {
+	order, ok := ctx.Value(orderKey{}).(Order)
+	return order, ok
+}
@@ -0,0 +1,49 @@
+package header
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/sunls24/gox/types"
+)
+
+func TestBuilderSetDelMerge(t *testing.T) {
+	b := New().Add(
+		types.NewPair("Accept", "a"),
+		types.NewPair("cookie", "1"),
+		types.NewPair("Cookie", "2"),
+		types.NewPair("Referer", "r"),
+	)
+	b.Set("Cookie", "3").Del("referer")
+	other := New().Add(
+		types.NewPair("accept", "b"),
+		types.NewPair("X-A", "1"),
+		types.NewPair("X-A", "2"),
+	).PseudoHeaderOrder(":method", ":authority", ":scheme", ":path")
+	b.Merge(other)
+
+	want := []types.Pair[string]{
+		types.NewPair("accept", "b"),
+		types.NewPair("Cookie", "3"),
+		types.NewPair("X-A", "1"),
+		types.NewPair("X-A", "2"),
+	}
+	if !slices.Equal(b.Get(), want) {
+		t.Fatalf("headers = %v, want %v", b.Get(), want)
+	}
+	order := b.Order()
+	if !slices.Equal(order.Headers, []string{"Accept", "Cookie", "X-A"}) {
+		t.Fatalf("order = %v", order.Headers)
+	}
+	if err := order.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	got, ok := OrderFromContext(ContextWithOrder(context.Background(), order))
+	if !ok || !slices.Equal(got.Pseudo, order.Pseudo) {
+		t.Fatalf("OrderFromContext() = %v, %v", got, ok)
+	}
+	if err := (Order{Pseudo: []string{":method"}}).Validate(); err == nil {
+		t.Fatal("Validate() error = nil, want error for incomplete pseudo order")
+	}
+}
@@ -0,0 +1,210 @@
+package header
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/sunls24/gox/types"
+)
+
+// Kind is the type of resource a request loads, it selects Accept and Sec-Fetch values
+type Kind int
+
+const (
+	Document Kind = iota
+	Fetch
+	Image
+	Script
+)
+
+type Profile struct {
+	name    string
+	headers []types.Pair[string]
+	kinds   map[Kind][]types.Pair[string]
+}
+
+func (p *Profile) Name() string {
+	return p.name
+}
+
+// Headers identity headers of the browser followed by the headers for kind
+func (p *Profile) Headers(kind Kind) []types.Pair[string] {
+	return append(slices.Clone(p.headers), p.kinds[kind]...)
+}
+
+const (
+	chromeVersion  = "147"
+	firefoxVersion = "149.0"
+
+	chromeLanguage  = "zh-CN,zh;q=0.9,en;q=0.8"
+	firefoxLanguage = "zh-CN,zh;q=0.8,zh-TW;q=0.7,zh-HK;q=0.5,en-US;q=0.3,en;q=0.2"
+	safariLanguage  = "zh-CN,zh-Hans;q=0.9"
+
+	chromeDocumentAccept  = "text/html,application/xhtml+xml,application/xml;q=0.9,image/avif,image/webp,image/apng,*/*;q=0.8,application/signed-exchange;v=b3;q=0.7"
+	chromeImageAccept     = "image/avif,image/webp,image/apng,image/svg+xml,image/*,*/*;q=0.8"
+	firefoxDocumentAccept = "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8"
+	firefoxImageAccept    = "image/avif,image/webp,image/png,image/svg+xml,image/*;q=0.8,*/*;q=0.5"
+	safariDocumentAccept  = "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8"
+	safariImageAccept     = "image/webp,image/avif,image/jxl,image/heic,image/heic-sequence,video/*;q=0.8,image/png,image/svg+xml,image/*;q=0.8,*/*;q=0.5"
+)
+
+var chromeUserAgents = map[string]string{
+	"Windows": "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/%s.0.0.0 Safari/537.36",
+	"macOS":   "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/%s.0.0.0 Safari/537.36",
+	"Linux":   "Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/%s.0.0.0 Safari/537.36",
+	"Android": "Mozilla/5.0 (Linux; Android 10; K) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/%s.0.0.0 Mobile Safari/537.36",
+}
+
+var firefoxPlatforms = map[string]string{
+	"Windows": "Windows NT 10.0; Win64; x64",
+	"macOS":   "Macintosh; Intel Mac OS X 10.15",
+	"Linux":   "X11; Linux x86_64",
+}
+
+var profiles = []*Profile{
+	chromium("chrome-macos", "Google Chrome", chromeVersion, "macOS", chromeLanguage),
+	chromium("chrome-windows", "Google Chrome", chromeVersion, "Windows", chromeLanguage),
+	chromium("chrome-android", "Google Chrome", chromeVersion, "Android", chromeLanguage),
+	chromium("edge-windows", "Microsoft Edge", chromeVersion, "Windows", chromeLanguage),
+	firefox("firefox-windows", firefoxVersion, "Windows", firefoxLanguage),
+	firefox("firefox-macos", firefoxVersion, "macOS", firefoxLanguage),
+	safari("safari-macos",
+		"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/26.0 Safari/605.1.15",
+		safariLanguage),
+	safari("safari-ios",
+		"Mozilla/5.0 (iPhone; CPU iPhone OS 18_6 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/26.0 Mobile/15E148 Safari/604.1",
+		safariLanguage),
+}
+
+// Profiles names of the built-in browser profiles
+func Profiles() []string {
+	names := make([]string, len(profiles))
+	for i, p := range profiles {
+		names[i] = p.name
+	}
+	return names
+}
+
+func GetProfile(name string) (*Profile, bool) {
+	for _, p := range profiles {
+		if p.name == name {
+			return p, true
+		}
+	}
+	return nil, false
+}
+
+// Profile adds the headers of a built-in browser profile, it panics for a name
+// not listed by Profiles, use GetProfile for names that come from input
+func (b *Builder) Profile(name string, kind Kind) *Builder {
+	p, ok := GetProfile(name)
+	if !ok {
+		panic(fmt.Sprintf("header: unknown browser profile %q", name))
+	}
+	return b.Add(p.Headers(kind)...)
+}
+
+// chromium brand is "Google Chrome" or "Microsoft Edge", version the major version
+func chromium(name, brand, version, platform, language string) *Profile {
+	userAgent := fmt.Sprintf(chromeUserAgents[platform], version)
+	if brand == "Microsoft Edge" {
+		userAgent += fmt.Sprintf(" Edg/%s.0.0.0", version)
+	}
+	return &Profile{
+		name: name,
+		headers: []types.Pair[string]{
+			types.NewPair("Sec-CH-UA", secCHUA(brand, version)),
+			types.NewPair("Sec-CH-UA-Mobile", chMobile(platform == "Android")),
+			types.NewPair("Sec-CH-UA-Platform", fmt.Sprintf("%q", platform)),
+			types.NewPair("User-Agent", userAgent),
+			types.NewPair("Accept-Language", language),
+		},
+		kinds: kinds(chromeDocumentAccept, chromeImageAccept, true),
+	}
+}
+
+// secCHUA follows the GREASE brand and order Chromium derives from the major version
+func secCHUA(brand, version string) string {
+	seed, _ := strconv.Atoi(version)
+	const chars = " (:-./);=?_"
+	grease := fmt.Sprintf("\"Not%cA%cBrand\";v=\"%s\"", chars[seed%len(chars)], chars[(seed+1)%len(chars)], []string{"8", "99", "24"}[seed%3])
+	orders := [6][3]int{{0, 1, 2}, {0, 2, 1}, {1, 0, 2}, {1, 2, 0}, {2, 0, 1}, {2, 1, 0}}
+	order := orders[seed%6]
+	var brands [3]string
+	brands[order[0]] = grease
+	brands[order[1]] = fmt.Sprintf("\"Chromium\";v=\"%s\"", version)
+	brands[order[2]] = fmt.Sprintf("\"%s\";v=\"%s\"", brand, version)
+	return strings.Join(brands[:], ", ")
+}
+
+func firefox(name, version, platform, language string) *Profile {
+	return &Profile{
+		name: name,
+		headers: []types.Pair[string]{
+			types.NewPair("User-Agent", fmt.Sprintf("Mozilla/5.0 (%s; rv:%s) Gecko/20100101 Firefox/%s", firefoxPlatforms[platform], version, version)),
+			types.NewPair("Accept-Language", language),
+		},
+		kinds: kinds(firefoxDocumentAccept, firefoxImageAccept, true),
+	}
+}
+
+func safari(name, userAgent, language string) *Profile {
+	return &Profile{
+		name: name,
+		headers: []types.Pair[string]{
+			types.NewPair("User-Agent", userAgent),
+			types.NewPair("Accept-Language", language),
+		},
+		kinds: kinds(safariDocumentAccept, safariImageAccept, false),
+	}
+}
+
+func chMobile(mobile bool) string {
+	if mobile {
+		return "?1"
+	}
+	return "?0"
+}
+
+// kinds Safari does not send Sec-Fetch-User
+func kinds(documentAccept, imageAccept string, fetchUser bool) map[Kind][]types.Pair[string] {
+	document := []types.Pair[string]{
+		types.NewPair("Accept", documentAccept),
+		types.NewPair("Sec-Fetch-Dest", "document"),
+		types.NewPair("Sec-Fetch-Mode", "navigate"),
+		types.NewPair("Sec-Fetch-Site", "none"),
+	}
+	if fetchUser {
+		document = append(document, types.NewPair("Sec-Fetch-User", "?1"))
+	}
+	document = append(document,
+		types.NewPair("Upgrade-Insecure-Requests", "1"),
+		types.NewPair("Priority", "u=0, i"),
+	)
+	return map[Kind][]types.Pair[string]{
+		Document: document,
+		Fetch: {
+			types.NewPair("Accept", "*/*"),
+			types.NewPair("Sec-Fetch-Dest", "empty"),
+			types.NewPair("Sec-Fetch-Mode", "cors"),
+			types.NewPair("Sec-Fetch-Site", "same-origin"),
+			types.NewPair("Priority", "u=1, i"),
+		},
+		Image: {
+			types.NewPair("Accept", imageAccept),
+			types.NewPair("Sec-Fetch-Dest", "image"),
+			types.NewPair("Sec-Fetch-Mode", "no-cors"),
+			types.NewPair("Sec-Fetch-Site", "same-origin"),
+			types.NewPair("Priority", "i"),
+		},
+		Script: {
+			types.NewPair("Accept", "*/*"),
+			types.NewPair("Sec-Fetch-Dest", "script"),
+			types.NewPair("Sec-Fetch-Mode", "no-cors"),
+			types.NewPair("Sec-Fetch-Site", "same-origin"),
+			types.NewPair("Priority", "u=1"),
+		},
+	}
+}
@@ -0,0 +1,67 @@
+package header
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestProfiles(t *testing.T) {
+	want := []string{
+		"chrome-macos", "chrome-windows", "chrome-android", "edge-windows",
+		"firefox-windows", "firefox-macos", "safari-macos", "safari-ios",
+	}
+	if names := Profiles(); !slices.Equal(names, want) {
+		t.Fatalf("Profiles() = %v, want %v", names, want)
+	}
+	for _, name := range want {
+		if p, ok := GetProfile(name); !ok || p.Name() != name {
+			t.Fatalf("GetProfile(%q) = %v, %v", name, p, ok)
+		}
+	}
+	if _, ok := GetProfile("chrome"); ok {
+		t.Fatal("GetProfile(chrome) ok = true, want false")
+	}
+}
+
+func TestProfileKinds(t *testing.T) {
+	p, _ := GetProfile("chrome-windows")
+	tests := []struct {
+		kind   Kind
+		accept string
+		dest   string
+		mode   string
+	}{
+		{Document, chromeDocumentAccept, "document", "navigate"},
+		{Fetch, "*/*", "empty", "cors"},
+		{Image, chromeImageAccept, "image", "no-cors"},
+		{Script, "*/*", "script", "no-cors"},
+	}
+	for _, tt := range tests {
+		headers := map[string]string{}
+		for _, pair := range p.Headers(tt.kind) {
+			headers[pair.Key] = pair.Value
+		}
+		if headers["Accept"] != tt.accept || headers["Sec-Fetch-Dest"] != tt.dest || headers["Sec-Fetch-Mode"] != tt.mode {
+			t.Errorf("kind %d headers = %v", tt.kind, headers)
+		}
+		if _, ok := headers["Sec-Fetch-User"]; ok != (tt.kind == Document) {
+			t.Errorf("kind %d Sec-Fetch-User present = %v", tt.kind, ok)
+		}
+	}
+}
+
+func TestSecCHUA(t *testing.T) {
+	want := chromeHeaders[2].Value
+	if got := secCHUA("Google Chrome", "147"); got != want {
+		t.Fatalf("secCHUA() = %s, want %s", got, want)
+	}
+}
+
+func TestBuilderProfileUnknown(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("Profile() did not panic for an unknown name")
+		}
+	}()
+	New().Profile("chrome-linux", Document)
+}
@@ -0,0 +1,236 @@
+package header
+
+import (
+	"cmp"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sunls24/gox/network/internal/reqbody"
+)
+
+// Signer adds authentication derived from the request itself, it is applied
+// right before sending and again for every retry
+type Signer interface {
+	Sign(req *http.Request) error
+}
+
+type SignerFunc func(req *http.Request) error
+
+func (f SignerFunc) Sign(req *http.Request) error {
+	return f(req)
+}
+
+// UnsignedPayload the X-Amz-Content-Sha256 value when SigV4.UnsignedPayload is set
+const UnsignedPayload = "UNSIGNED-PAYLOAD"
+
+const (
+	sigV4Algorithm       = "AWS4-HMAC-SHA256"
+	sigV4TimeFormat      = "20060102T150405Z"
+	amzContentSHA256     = "X-Amz-Content-Sha256"
+	amzDate              = "X-Amz-Date"
+	amzSecurityToken     = "X-Amz-Security-Token"
+	defaultHMACHeader    = "X-Signature"
+	defaultHMACTimestamp = "X-Timestamp"
+)
+
+// SigV4 AWS Signature Version 4 in the Authorization header, as used by S3
+// compatible storage. Host, Content-Type, Content-MD5, Range and X-Amz-*
+// headers are signed, paths are encoded once as S3 expects.
+type SigV4 struct {
+	AccessKey    string
+	SecretKey    string
+	SessionToken string
+	Region       string
+	// Service defaults to s3
+	Service string
+	// UnsignedPayload skips hashing the body, allowed by S3 over HTTPS
+	UnsignedPayload bool
+	// Now defaults to time.Now
+	Now func() time.Time
+}
+
+func (s *SigV4) Sign(req *http.Request) error {
+	if s.AccessKey == "" || s.SecretKey == "" || s.Region == "" {
+		return errors.New("sigv4: access key, secret key and region are required")
+	}
+	service := cmp.Or(s.Service, "s3")
+	now := time.Now
+	if s.Now != nil {
+		now = s.Now
+	}
+	t := now().UTC()
+	date := t.Format("20060102")
+
+	payload := UnsignedPayload
+	if !s.UnsignedPayload {
+		body, err := reqbody.Read(req)
+		if err != nil {
+			return err
+		}
+		payload = hashHex(body)
+	}
+	req.Header.Set(amzDate, t.Format(sigV4TimeFormat))
+	req.Header.Set(amzContentSHA256, payload)
+	if s.SessionToken != "" {
+		req.Header.Set(amzSecurityToken, s.SessionToken)
+	}
+	req.Header.Del("Authorization")
+
+	signed, canonicalHeaders := sigV4Headers(req)
+	canonical := strings.Join([]string{
+		req.Method,
+		sigV4Path(req.URL),
+		sigV4Query(req.URL.Query()),
+		canonicalHeaders,
+		signed,
+		payload,
+	}, "\n")
+	scope := date + "/" + s.Region + "/" + service + "/aws4_request"
+	stringToSign := strings.Join([]string{sigV4Algorithm, t.Format(sigV4TimeFormat), scope, hashHex([]byte(canonical))}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+s.SecretKey), date)
+	key = hmacSHA256(key, s.Region)
+	key = hmacSHA256(key, service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+	req.Header.Set("Authorization", sigV4Algorithm+" Credential="+s.AccessKey+"/"+scope+
+		", SignedHeaders="+signed+", Signature="+signature)
+	return nil
+}
+
+func sigV4Headers(req *http.Request) (string, string) {
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+	values := map[string]string{"host": host}
+	for key, v := range req.Header {
+		name := strings.ToLower(key)
+		switch {
+		case name == "content-type", name == "content-md5", name == "range", strings.HasPrefix(name, "x-amz-"):
+		default:
+			continue
+		}
+		trimmed := make([]string, len(v))
+		for i, value := range v {
+			trimmed[i] = strings.Join(strings.Fields(value), " ")
+		}
+		values[name] = strings.Join(trimmed, ",")
+	}
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	var b strings.Builder
+	for _, name := range names {
+		b.WriteString(name + ":" + values[name] + "\n")
+	}
+	return strings.Join(names, ";"), b.String()
+}
+
+func sigV4Path(u *url.URL) string {
+	path := u.Path
+	if path == "" {
+		return "/"
+	}
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		segments[i] = uriEncode(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+func sigV4Query(query url.Values) string {
+	pairs := make([]string, 0, len(query))
+	for key, values := range query {
+		for _, value := range values {
+			pairs = append(pairs, uriEncode(key)+"="+uriEncode(value))
+		}
+	}
+	slices.Sort(pairs)
+	return strings.Join(pairs, "&")
+}
+
+// uriEncode percent-encodes everything except the RFC 3986 unreserved characters
+func uriEncode(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' || c == '-' || c == '_' || c == '.' || c == '~' {
+			b.WriteByte(c)
+			continue
+		}
+		b.WriteString("%" + strings.ToUpper(hex.EncodeToString([]byte{c})))
+	}
+	return b.String()
+}
+
+// HMAC signs "METHOD\nPATH?QUERY\nTIMESTAMP\n[NONCE\n]BODY" with HMAC-SHA256,
+// the layout most payment and open platform APIs use. The timestamp is in Unix
+// seconds and sent in TimestampHeader, the signature in Header as lowercase hex
+// or standard base64.
+type HMAC struct {
+	Secret []byte
+	// Header defaults to X-Signature
+	Header string
+	// TimestampHeader defaults to X-Timestamp
+	TimestampHeader string
+	// NonceHeader enables a random nonce in the signed message when set
+	NonceHeader string
+	Base64      bool
+	// Now defaults to time.Now
+	Now func() time.Time
+}
+
+func (s *HMAC) Sign(req *http.Request) error {
+	if len(s.Secret) == 0 {
+		return errors.New("hmac: secret is required")
+	}
+	now := time.Now
+	if s.Now != nil {
+		now = s.Now
+	}
+	body, err := reqbody.Read(req)
+	if err != nil {
+		return err
+	}
+	timestamp := strconv.FormatInt(now().Unix(), 10)
+	message := []string{req.Method, req.URL.RequestURI(), timestamp}
+	if s.NonceHeader != "" {
+		nonce := rand.Text()
+		req.Header.Set(s.NonceHeader, nonce)
+		message = append(message, nonce)
+	}
+	mac := hmac.New(sha256.New, s.Secret)
+	mac.Write([]byte(strings.Join(message, "\n") + "\n"))
+	mac.Write(body)
+	signature := hex.EncodeToString(mac.Sum(nil))
+	if s.Base64 {
+		signature = base64.StdEncoding.EncodeToString(mac.Sum(nil))
+	}
+	req.Header.Set(cmp.Or(s.TimestampHeader, defaultHMACTimestamp), timestamp)
+	req.Header.Set(cmp.Or(s.Header, defaultHMACHeader), signature)
+	return nil
+}
+
+func hashHex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
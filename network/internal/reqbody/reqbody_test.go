@@ -0,0 +1,25 @@
+package reqbody
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestRead(t *testing.T) {
+	req, _ := http.NewRequest(http.MethodPost, "https://example.com/", io.NopCloser(strings.NewReader("data")))
+	for range 2 {
+		body, err := Read(req)
+		if err != nil || string(body) != "data" {
+			t.Fatalf("Read() = %q, %v, want data", body, err)
+		}
+	}
+	if req.GetBody == nil {
+		t.Fatal("GetBody = nil, want restored")
+	}
+	body, _ := io.ReadAll(req.Body)
+	if string(body) != "data" {
+		t.Fatalf("req.Body = %q, want data", body)
+	}
+}
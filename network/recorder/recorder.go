@@ -0,0 +1,258 @@
+package recorder
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/sunls24/gox/network/internal/reqbody"
+)
+
+type Mode int
+
+const (
+	// ModeReplay serves responses from the fixture and never touches the network
+	ModeReplay Mode = iota
+	// ModeRecord sends every request and rewrites the fixture
+	ModeRecord
+	// ModeAuto replays when the fixture exists, records otherwise
+	ModeAuto
+)
+
+const redacted = "REDACTED"
+
+var DefaultRedact = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie"}
+
+type Interaction struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+type Request struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Header http.Header `json:"header,omitempty"`
+	Body   Body        `json:"body,omitzero"`
+}
+
+type Response struct {
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       Body        `json:"body,omitzero"`
+}
+
+// Body is stored as text when it is valid UTF-8, base64 otherwise
+type Body []byte
+
+func (b Body) MarshalJSON() ([]byte, error) {
+	if utf8.Valid(b) {
+		return json.Marshal(string(b))
+	}
+	return json.Marshal(map[string]string{"base64": base64.StdEncoding.EncodeToString(b)})
+}
+
+func (b *Body) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err == nil {
+		*b = Body(text)
+		return nil
+	}
+	var encoded struct {
+		Base64 string `json:"base64"`
+	}
+	if err := json.Unmarshal(data, &encoded); err != nil {
+		return err
+	}
+	decoded, err := base64.StdEncoding.DecodeString(encoded.Base64)
+	if err != nil {
+		return err
+	}
+	*b = decoded
+	return nil
+}
+
+// Matcher reports whether a recorded request answers req, body is the request body
+type Matcher func(req *http.Request, body []byte, recorded *Request) bool
+
+func MatchMethodURL(req *http.Request, body []byte, recorded *Request) bool {
+	return req.Method == recorded.Method && req.URL.String() == recorded.URL
+}
+
+func MatchMethodURLBody(req *http.Request, body []byte, recorded *Request) bool {
+	return MatchMethodURL(req, body, recorded) && bytes.Equal(body, recorded.Body)
+}
+
+type Recorder struct {
+	path      string
+	mode      Mode
+	transport http.RoundTripper
+	redact    []string
+	match     Matcher
+
+	m            sync.Mutex
+	interactions []*Interaction
+	used         []bool
+}
+
+type Option func(*Recorder)
+
+func WithTransport(transport http.RoundTripper) Option {
+	return func(r *Recorder) {
+		if transport != nil {
+			r.transport = transport
+		}
+	}
+}
+
+// WithRedact replaces DefaultRedact
+func WithRedact(headers ...string) Option {
+	return func(r *Recorder) {
+		r.redact = headers
+	}
+}
+
+func WithMatcher(match Matcher) Option {
+	return func(r *Recorder) {
+		if match != nil {
+			r.match = match
+		}
+	}
+}
+
+// New path is the JSON fixture file
+func New(path string, mode Mode, opts ...Option) (*Recorder, error) {
+	r := &Recorder{
+		path:      path,
+		mode:      mode,
+		transport: http.DefaultTransport,
+		redact:    DefaultRedact,
+		match:     MatchMethodURL,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	if r.mode == ModeAuto {
+		r.mode = ModeRecord
+		if _, err := os.Stat(path); err == nil {
+			r.mode = ModeReplay
+		}
+	}
+	if r.mode == ModeReplay {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("read recorder fixture: %w", err)
+		}
+		if err := json.Unmarshal(data, &r.interactions); err != nil {
+			return nil, fmt.Errorf("parse recorder fixture: %w", err)
+		}
+		r.used = make([]bool, len(r.interactions))
+	}
+	return r, nil
+}
+
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	// the body is read from a clone, the caller's request is left untouched
+	req = req.Clone(req.Context())
+	body, err := reqbody.Read(req)
+	if err != nil {
+		return nil, fmt.Errorf("recorder: read request body: %w", err)
+	}
+	if r.mode == ModeReplay {
+		return r.replay(req, body)
+	}
+	return r.record(req, body)
+}
+
+func (r *Recorder) replay(req *http.Request, body []byte) (*http.Response, error) {
+	r.m.Lock()
+	defer r.m.Unlock()
+	// unused interactions first so repeated requests replay in recorded order
+	found := -1
+	for i, interaction := range r.interactions {
+		if !r.match(req, body, &interaction.Request) {
+			continue
+		}
+		found = i
+		if !r.used[i] {
+			break
+		}
+	}
+	if found < 0 {
+		return nil, fmt.Errorf("recorder: no interaction for %s %s", req.Method, req.URL)
+	}
+	r.used[found] = true
+	recorded := r.interactions[found].Response
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", recorded.StatusCode, http.StatusText(recorded.StatusCode)),
+		StatusCode:    recorded.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        recorded.Header.Clone(),
+		Body:          io.NopCloser(bytes.NewReader(recorded.Body)),
+		ContentLength: int64(len(recorded.Body)),
+		Request:       req,
+	}, nil
+}
+
+func (r *Recorder) record(req *http.Request, body []byte) (*http.Response, error) {
+	resp, err := r.transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(data))
+
+	r.m.Lock()
+	defer r.m.Unlock()
+	r.interactions = append(r.interactions, &Interaction{
+		Request: Request{
+			Method: req.Method,
+			URL:    req.URL.String(),
+			Header: r.redactHeader(req.Header),
+			Body:   body,
+		},
+		Response: Response{
+			StatusCode: resp.StatusCode,
+			Header:     r.redactHeader(resp.Header),
+			Body:       data,
+		},
+	})
+	if err := r.save(); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (r *Recorder) save() error {
+	data, err := json.MarshalIndent(r.interactions, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(r.path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(r.path, data, 0o644)
+}
+
+func (r *Recorder) redactHeader(header http.Header) http.Header {
+	header = header.Clone()
+	for _, key := range r.redact {
+		if _, ok := header[http.CanonicalHeaderKey(key)]; ok {
+			header.Set(key, redacted)
+		}
+	}
+	return header
+}
@@ -0,0 +1,57 @@
+package recorder
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sunls24/gox/network/client"
+	"github.com/sunls24/gox/network/header"
+)
+
+func TestRecordReplay(t *testing.T) {
+	var calls int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Set-Cookie", "session=secret")
+		_, _ = w.Write([]byte("hello " + r.URL.Query().Get("n")))
+	}))
+	path := filepath.Join(t.TempDir(), "fixture.json")
+	token := header.New().Authorization("secret").Get()
+
+	rec, err := New(path, ModeAuto)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	c := client.New(client.WithTransport(rec))
+	for _, n := range []string{"1", "2"} {
+		if _, err := c.Get(context.Background(), server.URL+"?n="+n, token...); err != nil {
+			t.Fatalf("record Get() error = %v", err)
+		}
+	}
+	server.Close()
+	fixture, _ := os.ReadFile(path)
+	if strings.Contains(string(fixture), "secret") {
+		t.Fatalf("fixture contains unredacted secret: %s", fixture)
+	}
+
+	rec, err = New(path, ModeAuto)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	c = client.New(client.WithTransport(rec))
+	body, err := c.Get(context.Background(), server.URL+"?n=2")
+	if err != nil {
+		t.Fatalf("replay Get() error = %v", err)
+	}
+	if string(body) != "hello 2" || calls != 2 {
+		t.Fatalf("body = %q, calls = %d, want replayed hello 2", body, calls)
+	}
+	if _, err := c.Get(context.Background(), server.URL+"?n=3"); err == nil {
+		t.Fatal("replay Get() of unknown request error = nil")
+	}
+}
@@ -11,9 +11,10 @@ import (
 )
 
 type OpenAI struct {
-	baseURL string
-	apiKey  string
-	client  *client.Client
+	baseURL    string
+	apiKey     string
+	httpClient *http.Client
+	client     *client.Client
 }
 
 type Option func(*OpenAI)
@@ -22,37 +23,30 @@ func New(baseURL, apiKey string, opts ...Option) *OpenAI {
 	oai := &OpenAI{
 		baseURL: strings.TrimRight(baseURL, "/"),
 		apiKey:  strings.TrimSpace(apiKey),
-		client:  client.New(),
 	}
 
 	for _, opt := range opts {
 		opt(oai)
 	}
 
+	oai.client = client.New(
+		client.WithClient(oai.httpClient),
+		client.WithBaseURL(oai.baseURL),
+		client.WithHeader(header.New().ContentTypeJSON().Authorization(oai.apiKey).Get()...),
+	)
 	return oai
 }
 
 func WithClient(c *http.Client) Option {
 	return func(oai *OpenAI) {
-		oai.client = client.New(client.WithClient(c))
+		oai.httpClient = c
 	}
 }
 
 func (oai *OpenAI) Responses(ctx context.Context, req Request) (io.ReadCloser, error) {
-	const path = "/responses"
-	return oai.postReader(ctx, path, req)
+	return oai.client.PostReader(ctx, "/responses", req)
 }
 
 func (oai *OpenAI) ChatCompletions(ctx context.Context, req ChatRequest) (io.ReadCloser, error) {
-	const path = "/chat/completions"
-	return oai.postReader(ctx, path, req)
-}
-
-func (oai *OpenAI) postReader(ctx context.Context, path string, body any) (io.ReadCloser, error) {
-	return oai.client.PostReader(
-		ctx,
-		oai.baseURL+path,
-		body,
-		header.New().ContentTypeJSON().Authorization(oai.apiKey).Get()...,
-	)
+	return oai.client.PostReader(ctx, "/chat/completions", req)
 }